@@ -0,0 +1,79 @@
+package auth
+
+import (
+	_ "embed"
+	"errors"
+	"strings"
+)
+
+var ErrPasswordBlocked = errors.New("password is too common")
+
+//go:embed blocklist.txt
+var defaultBlocklist string
+
+// substitutions undoes the common character swaps people use to dress up a
+// weak password, e.g. "P@ssw0rd".
+var substitutions = strings.NewReplacer(
+	"0", "o",
+	"1", "i",
+	"!", "i",
+	"3", "e",
+	"4", "a",
+	"@", "a",
+	"5", "s",
+	"$", "s",
+	"7", "t",
+)
+
+// PasswordBlocklist rejects common passwords regardless of how strong they
+// look otherwise. Matching is case-insensitive.
+type PasswordBlocklist struct {
+	blocked     map[string]struct{}
+	substitutes bool
+}
+
+// NewPasswordBlocklist blocks the given passwords. With substitutes set,
+// character swaps like "0" for "o" do not get a blocked password through.
+func NewPasswordBlocklist(passwords []string, substitutes bool) *PasswordBlocklist {
+	b := &PasswordBlocklist{
+		blocked:     make(map[string]struct{}, len(passwords)),
+		substitutes: substitutes,
+	}
+	for _, password := range passwords {
+		if password = strings.TrimSpace(password); password != "" {
+			b.blocked[b.key(password)] = struct{}{}
+		}
+	}
+
+	return b
+}
+
+// DefaultPasswordBlocklist blocks the embedded list of common passwords.
+func DefaultPasswordBlocklist(substitutes bool) *PasswordBlocklist {
+	return NewPasswordBlocklist(strings.Split(defaultBlocklist, "\n"), substitutes)
+}
+
+// IsBlocked reports whether the password is on the blocklist.
+func (b *PasswordBlocklist) IsBlocked(plain string) bool {
+	_, ok := b.blocked[b.key(plain)]
+	return ok
+}
+
+// Check returns ErrPasswordBlocked for a blocked password, for use when a
+// password is set at registration or reset.
+func (b *PasswordBlocklist) Check(plain string) error {
+	if b.IsBlocked(plain) {
+		return ErrPasswordBlocked
+	}
+
+	return nil
+}
+
+func (b *PasswordBlocklist) key(password string) string {
+	password = strings.ToLower(password)
+	if b.substitutes {
+		password = substitutions.Replace(password)
+	}
+
+	return password
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordBlocklist(t *testing.T) {
+	blocklist := DefaultPasswordBlocklist(false)
+
+	assert.True(t, blocklist.IsBlocked("password123"))
+	assert.True(t, blocklist.IsBlocked("PassWord123"))
+	assert.False(t, blocklist.IsBlocked("correct horse battery staple"))
+	assert.False(t, blocklist.IsBlocked(""))
+
+	assert.ErrorIs(t, blocklist.Check("qwerty"), ErrPasswordBlocked)
+	assert.NoError(t, blocklist.Check("correct horse battery staple"))
+
+	// Substitutions only count when enabled
+	assert.False(t, blocklist.IsBlocked("P@ssw0rd123"))
+	assert.True(t, DefaultPasswordBlocklist(true).IsBlocked("P@ssw0rd123"))
+}
+
+func TestNewPasswordBlocklist(t *testing.T) {
+	blocklist := NewPasswordBlocklist([]string{"Hunter2", " "}, true)
+
+	assert.True(t, blocklist.IsBlocked("hunter2"))
+	assert.True(t, blocklist.IsBlocked("HUNT3R2"))
+	assert.False(t, blocklist.IsBlocked("password"))
+	assert.False(t, blocklist.IsBlocked(" "))
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidToken = errors.New("invalid access token")
+
+// TokenManager issues and validates short-lived HS256 access tokens.
+type TokenManager struct {
+	keys *KeySet
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewTokenManager signs with a single secret. Its tokens carry no kid header.
+func NewTokenManager(secret string, ttl time.Duration) *TokenManager {
+	return NewTokenManagerWithKeySet(NewKeySet("", []byte(secret)), ttl)
+}
+
+// NewTokenManagerWithKeySet signs with the current key of keys and accepts
+// tokens signed with any key still in the set, so keys can be rotated
+// without logging everyone out.
+func NewTokenManagerWithKeySet(keys *KeySet, ttl time.Duration) *TokenManager {
+	return &TokenManager{
+		keys: keys,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// GenerateAccessToken returns a signed JWT for the user with sub, iat and exp
+// claims.
+func (m *TokenManager) GenerateAccessToken(userID uuid.UUID) (string, error) {
+	return m.sign(m.claims(userID))
+}
+
+// GenerateSessionAccessToken is like GenerateAccessToken but also binds the
+// token to a session, the id of the refresh token it was issued with, through
+// the jti claim.
+func (m *TokenManager) GenerateSessionAccessToken(userID, sessionID uuid.UUID) (string, error) {
+	claims := m.claims(userID)
+	claims.ID = sessionID.String()
+
+	return m.sign(claims)
+}
+
+func (m *TokenManager) claims(userID uuid.UUID) jwt.RegisteredClaims {
+	now := m.now()
+	return jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+	}
+}
+
+func (m *TokenManager) sign(claims jwt.RegisteredClaims) (string, error) {
+	kid, key := m.keys.signingKey()
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if kid != "" {
+		unsigned.Header["kid"] = kid
+	}
+
+	token, err := unsigned.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ParseAccessToken validates the signature against the key named by the kid
+// header, and the expiry, and returns the user id. Only HS256 is accepted,
+// which also rules out "alg: none". Any failure is reported as
+// ErrInvalidToken.
+func (m *TokenManager) ParseAccessToken(token string) (uuid.UUID, error) {
+	claims, err := m.parse(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: bad subject: %w", ErrInvalidToken, err)
+	}
+
+	return userID, nil
+}
+
+// ParseSessionAccessToken validates the token like ParseAccessToken and also
+// returns the session id from its jti claim. Tokens without a session are
+// rejected with ErrInvalidToken.
+func (m *TokenManager) ParseSessionAccessToken(token string) (userID, sessionID uuid.UUID, err error) {
+	claims, err := m.parse(token)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	userID, err = uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("%w: bad subject: %w", ErrInvalidToken, err)
+	}
+
+	sessionID, err = uuid.Parse(claims.ID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("%w: bad session id: %w", ErrInvalidToken, err)
+	}
+
+	return userID, sessionID, nil
+}
+
+func (m *TokenManager) parse(token string) (*jwt.RegisteredClaims, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := m.keys.verificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return &claims, nil
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenManager_RoundTrip(t *testing.T) {
+	manager := NewTokenManager("secret", 15*time.Minute)
+	userID := uuid.New()
+
+	token, err := manager.GenerateAccessToken(userID)
+	assert.NoError(t, err)
+
+	parsed, err := manager.ParseAccessToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, parsed)
+}
+
+func TestTokenManager_SessionRoundTrip(t *testing.T) {
+	manager := NewTokenManager("secret", 15*time.Minute)
+	userID, sessionID := uuid.New(), uuid.New()
+
+	token, err := manager.GenerateSessionAccessToken(userID, sessionID)
+	assert.NoError(t, err)
+
+	parsedUser, parsedSession, err := manager.ParseSessionAccessToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, parsedUser)
+	assert.Equal(t, sessionID, parsedSession)
+
+	// A token without a session cannot be used where one is required
+	token, err = manager.GenerateAccessToken(userID)
+	assert.NoError(t, err)
+	_, _, err = manager.ParseSessionAccessToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenManager_Expired(t *testing.T) {
+	manager := NewTokenManager("secret", 15*time.Minute)
+	manager.now = func() time.Time { return time.Now().Add(-time.Hour) }
+
+	token, err := manager.GenerateAccessToken(uuid.New())
+	assert.NoError(t, err)
+
+	manager.now = time.Now
+	_, err = manager.ParseAccessToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenManager_WrongSecret(t *testing.T) {
+	token, err := NewTokenManager("secret", 15*time.Minute).GenerateAccessToken(uuid.New())
+	assert.NoError(t, err)
+
+	_, err = NewTokenManager("other-secret", 15*time.Minute).ParseAccessToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenManager_RejectsAlgNone(t *testing.T) {
+	claims := jwt.RegisteredClaims{
+		Subject:   uuid.New().String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.NoError(t, err)
+
+	_, err = NewTokenManager("secret", 15*time.Minute).ParseAccessToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
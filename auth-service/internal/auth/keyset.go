@@ -0,0 +1,55 @@
+package auth
+
+import "sync"
+
+// KeySet holds the HMAC key new access tokens are signed with plus retired
+// keys that are still accepted for verification, each under a key id that is
+// written to the token's kid header. Rotating the key therefore does not
+// invalidate tokens signed with the previous one until it is removed.
+type KeySet struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+// NewKeySet returns a set signing with key under kid.
+func NewKeySet(kid string, key []byte) *KeySet {
+	return &KeySet{
+		current: kid,
+		keys:    map[string][]byte{kid: key},
+	}
+}
+
+// Rotate makes key the signing key under kid. The previous signing key stays
+// valid for verification until removed.
+func (s *KeySet) Rotate(kid string, key []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = kid
+	s.keys[kid] = key
+}
+
+// Remove stops accepting tokens signed under kid. The current signing key
+// cannot be removed.
+func (s *KeySet) Remove(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kid != s.current {
+		delete(s.keys, kid)
+	}
+}
+
+// signingKey returns the current key and its id.
+func (s *KeySet) signingKey() (string, []byte) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current, s.keys[s.current]
+}
+
+// verificationKey returns the key registered under kid.
+func (s *KeySet) verificationKey(kid string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenManager_KeyRotation(t *testing.T) {
+	keys := NewKeySet("2024-01", []byte("old-secret"))
+	manager := NewTokenManagerWithKeySet(keys, 15*time.Minute)
+	userID := uuid.New()
+
+	before, err := manager.GenerateAccessToken(userID)
+	assert.NoError(t, err)
+
+	keys.Rotate("2024-02", []byte("new-secret"))
+
+	after, err := manager.GenerateAccessToken(userID)
+	assert.NoError(t, err)
+
+	for _, token := range []string{before, after} {
+		parsed, err := manager.ParseAccessToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, userID, parsed)
+	}
+
+	// Tokens signed with the new key do not validate under the old id
+	_, err = NewTokenManagerWithKeySet(NewKeySet("2024-01", []byte("old-secret")), 15*time.Minute).ParseAccessToken(after)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	keys.Remove("2024-01")
+
+	_, err = manager.ParseAccessToken(before)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = manager.ParseAccessToken(after)
+	assert.NoError(t, err)
+
+	// The signing key is never removed
+	keys.Remove("2024-02")
+	_, err = manager.ParseAccessToken(after)
+	assert.NoError(t, err)
+}
+
+func TestTokenManager_KeySetRejectsUnkeyedTokens(t *testing.T) {
+	token, err := NewTokenManager("secret", 15*time.Minute).GenerateAccessToken(uuid.New())
+	assert.NoError(t, err)
+
+	_, err = NewTokenManagerWithKeySet(NewKeySet("2024-01", []byte("secret")), 15*time.Minute).ParseAccessToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
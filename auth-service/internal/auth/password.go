@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordBytes is the longest input bcrypt uses; anything beyond it would
+// be silently ignored.
+const maxPasswordBytes = 72
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrPasswordTooLong    = errors.New("password exceeds 72 bytes")
+)
+
+// PasswordHasher hashes and verifies passwords with bcrypt.
+type PasswordHasher struct {
+	cost int
+}
+
+// NewPasswordHasher returns a hasher using the given bcrypt cost. A zero cost
+// means bcrypt.DefaultCost.
+func NewPasswordHasher(cost int) (*PasswordHasher, error) {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("invalid bcrypt cost %d", cost)
+	}
+
+	return &PasswordHasher{
+		cost: cost,
+	}, nil
+}
+
+// Algorithm identifies the hashes this hasher produces, e.g. "bcrypt-12",
+// for recording next to the hash so outdated ones can be found.
+func (h *PasswordHasher) Algorithm() string {
+	return fmt.Sprintf("bcrypt-%d", h.cost)
+}
+
+// Hash returns the bcrypt hash of the password. Passwords longer than 72
+// bytes are rejected rather than truncated.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if len(password) > maxPasswordBytes {
+		return "", ErrPasswordTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+// Compare checks the password against the hash and returns
+// ErrInvalidCredentials on any mismatch.
+func (h *PasswordHasher) Compare(hash, password string) error {
+	if len(password) > maxPasswordBytes {
+		return ErrInvalidCredentials
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrInvalidCredentials
+		}
+		return fmt.Errorf("failed to compare password: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	hash, err := hasher.Hash("correct horse battery staple")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "correct horse battery staple", hash)
+
+	assert.NoError(t, hasher.Compare(hash, "correct horse battery staple"))
+	assert.Equal(t, ErrInvalidCredentials, hasher.Compare(hash, "wrong password"))
+}
+
+func TestPasswordHasher_TooLong(t *testing.T) {
+	hasher, err := NewPasswordHasher(bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	// bcrypt would ignore everything after the 72nd byte
+	long := strings.Repeat("a", 72)
+	hash, err := hasher.Hash(long)
+	assert.NoError(t, err)
+
+	_, err = hasher.Hash(long + "b")
+	assert.ErrorIs(t, err, ErrPasswordTooLong)
+
+	assert.Equal(t, ErrInvalidCredentials, hasher.Compare(hash, long+"b"))
+}
+
+func TestNewPasswordHasher_Cost(t *testing.T) {
+	hasher, err := NewPasswordHasher(0)
+	assert.NoError(t, err)
+	assert.Equal(t, bcrypt.DefaultCost, hasher.cost)
+	assert.Equal(t, "bcrypt-10", hasher.Algorithm())
+
+	_, err = NewPasswordHasher(bcrypt.MaxCost + 1)
+	assert.Error(t, err)
+}
@@ -10,6 +10,7 @@ type User struct {
 	Name         string
 	Email        string
 	PasswordHash string
+	Phone        *string
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -19,6 +20,22 @@ type RefreshToken struct {
 	UserID       uuid.UUID
 	RefreshToken string
 	ExpiresAt    time.Time
+	Revoked      bool
+	UseCount     int
+	FamilyID     uuid.UUID
+	RotatedAt    *time.Time
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
+
+type Session struct {
+	User         *User
+	RefreshToken *RefreshToken
+}
+
+type LoginAttempt struct {
+	UserID    uuid.UUID
+	Success   bool
+	IP        string
+	CreatedAt time.Time
+}
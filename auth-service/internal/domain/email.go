@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrInvalidEmail        = errors.New("invalid email")
+	ErrUndeliverableDomain = errors.New("email domain does not accept mail")
+)
+
+// NormalizeEmail returns the canonical form emails are stored and looked up
+// in: trimmed and lowercased.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// EmailDomain returns the normalized part of the email after its last "@",
+// or "" when there is none.
+func EmailDomain(email string) string {
+	email = NormalizeEmail(email)
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return ""
+	}
+
+	return email[i+1:]
+}
+
+// confusables maps lowercase Cyrillic and Greek letters to the Latin letters
+// they are visually indistinguishable from.
+var confusables = map[rune]rune{
+	'а': 'a', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j', 'к': 'k',
+	'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'у': 'y', 'х': 'x', 'ԝ': 'w',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// EmailLookupKey returns the key used to detect emails that look the same:
+// the NFKC form of the normalized email, with confusable letters folded to
+// their Latin look-alikes when foldConfusables is set.
+func EmailLookupKey(email string, foldConfusables bool) string {
+	key := NormalizeEmail(norm.NFKC.String(email))
+	if !foldConfusables {
+		return key
+	}
+
+	return strings.Map(func(r rune) rune {
+		if latin, ok := confusables[r]; ok {
+			return latin
+		}
+		return r
+	}, key)
+}
+
+// MXResolver looks up mail exchangers for a domain. *net.Resolver satisfies it.
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// EmailValidator checks that an email is well formed and, when CheckMX is
+// enabled, that its domain publishes MX records. The MX check hits the
+// network, so it is off unless explicitly requested.
+type EmailValidator struct {
+	resolver MXResolver
+	checkMX  bool
+	timeout  time.Duration
+}
+
+func NewEmailValidator(resolver MXResolver, checkMX bool, timeout time.Duration) *EmailValidator {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &EmailValidator{
+		resolver: resolver,
+		checkMX:  checkMX,
+		timeout:  timeout,
+	}
+}
+
+func (v *EmailValidator) ValidateEmailDeliverable(ctx context.Context, email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return ErrInvalidEmail
+	}
+	_, host, _ := strings.Cut(addr.Address, "@")
+
+	if !v.checkMX {
+		return nil
+	}
+
+	if v.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.timeout)
+		defer cancel()
+	}
+
+	records, err := v.resolver.LookupMX(ctx, host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return ErrUndeliverableDomain
+		}
+		return fmt.Errorf("failed to look up MX records: %w", err)
+	}
+
+	// A single "." record is a null MX (RFC 7505): the domain accepts no mail.
+	if len(records) == 0 || (len(records) == 1 && records[0].Host == ".") {
+		return ErrUndeliverableDomain
+	}
+
+	return nil
+}
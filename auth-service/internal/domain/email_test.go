@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubResolver map[string][]*net.MX
+
+func (s stubResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	records, ok := s[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func TestEmailValidator_ValidateEmailDeliverable(t *testing.T) {
+	resolver := stubResolver{
+		"example.com": {{Host: "mx.example.com.", Pref: 10}},
+	}
+	validator := NewEmailValidator(resolver, true, time.Second)
+
+	assert.NoError(t, validator.ValidateEmailDeliverable(context.Background(), "alice@example.com"))
+	assert.ErrorIs(t, validator.ValidateEmailDeliverable(context.Background(), "alice@gmial.com"), ErrUndeliverableDomain)
+	assert.ErrorIs(t, validator.ValidateEmailDeliverable(context.Background(), "not-an-email"), ErrInvalidEmail)
+}
+
+func TestEmailValidator_MXCheckDisabled(t *testing.T) {
+	validator := NewEmailValidator(stubResolver{}, false, time.Second)
+
+	assert.NoError(t, validator.ValidateEmailDeliverable(context.Background(), "alice@gmial.com"))
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "alice@example.com", NormalizeEmail("  Alice@Example.COM\t"))
+	assert.Equal(t, "alice@example.com", NormalizeEmail("alice@example.com"))
+}
+
+func TestEmailDomain(t *testing.T) {
+	assert.Equal(t, "example.com", EmailDomain(" Alice@Example.COM "))
+	assert.Equal(t, "evil.org", EmailDomain(`"a@b"@evil.org`))
+	assert.Equal(t, "", EmailDomain("not-an-email"))
+}
+
+func TestEmailLookupKey(t *testing.T) {
+	// Fullwidth letters collapse under NFKC alone
+	assert.Equal(t, "alice@example.com", EmailLookupKey("ａｌｉｃｅ@example.com", false))
+
+	// Cyrillic "а" and "е" only collide with folding enabled
+	homoglyph := "\u0430lic\u0435@example.com"
+	assert.NotEqual(t, "alice@example.com", EmailLookupKey(homoglyph, false))
+	assert.Equal(t, "alice@example.com", EmailLookupKey(homoglyph, true))
+	assert.Equal(t, EmailLookupKey("Alice@Example.com", true), EmailLookupKey(homoglyph, true))
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserExport is the data-portability bundle for a single user. It never
+// contains the password hash or refresh token values.
+type UserExport struct {
+	ExportedAt time.Time         `json:"exported_at"`
+	Profile    ExportedProfile   `json:"profile"`
+	Sessions   []ExportedSession `json:"sessions"`
+}
+
+type ExportedProfile struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     *string   `json:"phone"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ExportedSession struct {
+	ID        uuid.UUID `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Revoked   bool      `json:"revoked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewUserExport builds the bundle from the stored user and their tokens.
+func NewUserExport(user *User, tokens []*RefreshToken, exportedAt time.Time) *UserExport {
+	export := &UserExport{
+		ExportedAt: exportedAt,
+		Profile: ExportedProfile{
+			ID:        user.ID,
+			Name:      user.Name,
+			Email:     user.Email,
+			Phone:     user.Phone,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		},
+		Sessions: make([]ExportedSession, 0, len(tokens)),
+	}
+	for _, token := range tokens {
+		export.Sessions = append(export.Sessions, ExportedSession{
+			ID:        token.ID,
+			ExpiresAt: token.ExpiresAt,
+			Revoked:   token.Revoked,
+			CreatedAt: token.CreatedAt,
+		})
+	}
+
+	return export
+}
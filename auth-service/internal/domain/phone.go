@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// e164Pattern matches "+" followed by a country code and subscriber number,
+// at most 15 digits in total.
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+
+// ValidatePhone checks that phone is already in E.164 form.
+func ValidatePhone(phone string) error {
+	if !e164Pattern.MatchString(phone) {
+		return ErrInvalidPhone
+	}
+
+	return nil
+}
+
+// NormalizePhone converts a phone number to E.164. Spaces, dashes, dots and
+// parentheses are dropped and an international "00" prefix becomes "+".
+// Numbers without a country code are prefixed with defaultCountryCode
+// (digits only, e.g. "1" or "44") after stripping a national trunk "0".
+func NormalizePhone(raw, defaultCountryCode string) (string, error) {
+	phone := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+
+	switch {
+	case strings.HasPrefix(phone, "+"):
+	case strings.HasPrefix(phone, "00"):
+		phone = "+" + strings.TrimPrefix(phone, "00")
+	case defaultCountryCode != "":
+		phone = "+" + defaultCountryCode + strings.TrimPrefix(phone, "0")
+	default:
+		return "", ErrInvalidPhone
+	}
+
+	if err := ValidatePhone(phone); err != nil {
+		return "", err
+	}
+
+	return phone, nil
+}
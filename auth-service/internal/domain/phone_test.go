@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	phone, err := NormalizePhone("+14155552671", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "+14155552671", phone)
+
+	phone, err = NormalizePhone("(415) 555-2671", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "+14155552671", phone)
+
+	phone, err = NormalizePhone("020 7946 0018", "44")
+	assert.NoError(t, err)
+	assert.Equal(t, "+442079460018", phone)
+
+	phone, err = NormalizePhone("0049 30 123456", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "+4930123456", phone)
+
+	for _, raw := range []string{"", "12345", "+1-800-FLOWERS", "555-2671", "+1234567890123456"} {
+		_, err := NormalizePhone(raw, "")
+		assert.ErrorIs(t, err, ErrInvalidPhone, raw)
+	}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenStatus classifies a presented refresh token.
+type TokenStatus int
+
+const (
+	TokenNotFound TokenStatus = iota
+	TokenValid
+	TokenExpired
+	TokenRevoked
+)
+
+func (s TokenStatus) String() string {
+	switch s {
+	case TokenValid:
+		return "valid"
+	case TokenExpired:
+		return "expired"
+	case TokenRevoked:
+		return "revoked"
+	default:
+		return "not_found"
+	}
+}
+
+// Status reports whether the token is valid at now. Revocation takes
+// precedence over expiry.
+func (t RefreshToken) Status(now time.Time) TokenStatus {
+	switch {
+	case t.Revoked:
+		return TokenRevoked
+	case !now.Before(t.ExpiresAt):
+		return TokenExpired
+	default:
+		return TokenValid
+	}
+}
+
+// ShouldRefresh reports whether a client should refresh the token now, i.e.
+// whether now is within skew of the expiry (or past it).
+func (t RefreshToken) ShouldRefresh(now time.Time, skew time.Duration) bool {
+	return !now.Before(t.ExpiresAt.Add(-skew))
+}
+
+// SessionInfo is the client-facing view of a refresh token. Its ID is the
+// stable session identifier; the secret token value is never exposed.
+type SessionInfo struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// SessionInfo returns the client-facing view of the token.
+func (t RefreshToken) SessionInfo() SessionInfo {
+	return SessionInfo{
+		ID:        t.ID,
+		CreatedAt: t.CreatedAt,
+		ExpiresAt: t.ExpiresAt,
+	}
+}
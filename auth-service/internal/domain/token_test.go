@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshToken_ShouldRefresh(t *testing.T) {
+	expiresAt := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	token := RefreshToken{ExpiresAt: expiresAt}
+	skew := 5 * time.Minute
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"well before the window", expiresAt.Add(-time.Hour), false},
+		{"just before the window", expiresAt.Add(-skew - time.Nanosecond), false},
+		{"at the window start", expiresAt.Add(-skew), true},
+		{"inside the window", expiresAt.Add(-time.Minute), true},
+		{"already expired", expiresAt.Add(time.Minute), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, token.ShouldRefresh(tt.now, skew))
+		})
+	}
+}
+
+func TestRefreshToken_Status(t *testing.T) {
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, TokenValid, RefreshToken{ExpiresAt: now.Add(time.Minute)}.Status(now))
+	assert.Equal(t, TokenExpired, RefreshToken{ExpiresAt: now}.Status(now))
+	assert.Equal(t, TokenRevoked, RefreshToken{ExpiresAt: now.Add(time.Minute), Revoked: true}.Status(now))
+	assert.Equal(t, TokenRevoked, RefreshToken{ExpiresAt: now.Add(-time.Minute), Revoked: true}.Status(now))
+	assert.Equal(t, "not_found", TokenNotFound.String())
+}
+
+func TestRefreshToken_SessionInfo(t *testing.T) {
+	token := RefreshToken{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		RefreshToken: "secret_refresh_token",
+		ExpiresAt:    time.Now().Add(time.Hour),
+		CreatedAt:    time.Now(),
+	}
+
+	info := token.SessionInfo()
+	assert.Equal(t, token.ID, info.ID)
+	assert.Equal(t, token.CreatedAt, info.CreatedAt)
+	assert.Equal(t, token.ExpiresAt, info.ExpiresAt)
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const earthRadiusKm = 6371.0
+
+// GeoPoint is a position in decimal degrees.
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// DistanceKm returns the great-circle distance to other.
+func (p GeoPoint) DistanceKm(other GeoPoint) float64 {
+	lat1, lat2 := p.Latitude*math.Pi/180, other.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (other.Longitude - p.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// GeoResolver maps an IP address to an approximate location, e.g. backed by
+// a GeoIP database.
+type GeoResolver interface {
+	Resolve(ctx context.Context, ip string) (GeoPoint, error)
+}
+
+// TokenUse is a single presentation of a refresh token. Location is nil until
+// resolved.
+type TokenUse struct {
+	IP       string
+	At       time.Time
+	Location *GeoPoint
+}
+
+// Locate resolves the location of the use's IP address.
+func (u *TokenUse) Locate(ctx context.Context, resolver GeoResolver) error {
+	point, err := resolver.Resolve(ctx, u.IP)
+	if err != nil {
+		return fmt.Errorf("failed to locate %s: %w", u.IP, err)
+	}
+	u.Location = &point
+
+	return nil
+}
+
+// DetectImpossibleTravel reports whether getting from prev's location to
+// next's in the time between them requires travelling faster than
+// maxSpeedKmh. Uses without a resolved location are never flagged.
+func DetectImpossibleTravel(prev, next TokenUse, maxSpeedKmh float64) bool {
+	if prev.Location == nil || next.Location == nil {
+		return false
+	}
+
+	distance := prev.Location.DistanceKm(*next.Location)
+	hours := math.Abs(next.At.Sub(prev.At).Hours())
+	if hours == 0 {
+		return distance > 0
+	}
+
+	return distance/hours > maxSpeedKmh
+}
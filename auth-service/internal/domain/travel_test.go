@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticResolver map[string]GeoPoint
+
+func (r staticResolver) Resolve(_ context.Context, ip string) (GeoPoint, error) {
+	point, ok := r[ip]
+	if !ok {
+		return GeoPoint{}, errors.New("unknown ip")
+	}
+	return point, nil
+}
+
+var (
+	berlin  = GeoPoint{Latitude: 52.52, Longitude: 13.405}
+	paris   = GeoPoint{Latitude: 48.8566, Longitude: 2.3522}
+	sydney  = GeoPoint{Latitude: -33.8688, Longitude: 151.2093}
+	resolve = staticResolver{"198.51.100.1": berlin, "198.51.100.2": paris, "203.0.113.9": sydney}
+)
+
+func TestGeoPoint_DistanceKm(t *testing.T) {
+	assert.InDelta(t, 878, berlin.DistanceKm(paris), 5)
+	assert.Zero(t, berlin.DistanceKm(berlin))
+}
+
+func TestDetectImpossibleTravel(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	uses := []TokenUse{
+		{IP: "198.51.100.1", At: start},
+		{IP: "198.51.100.2", At: start.Add(3 * time.Hour)},
+		{IP: "203.0.113.9", At: start.Add(4 * time.Hour)},
+	}
+	for i := range uses {
+		assert.NoError(t, uses[i].Locate(context.Background(), resolve))
+	}
+
+	// Berlin to Paris in three hours is a flight
+	assert.False(t, DetectImpossibleTravel(uses[0], uses[1], 1000))
+	// Paris to Sydney in one hour is not
+	assert.True(t, DetectImpossibleTravel(uses[1], uses[2], 1000))
+	// Simultaneous uses in different places
+	assert.True(t, DetectImpossibleTravel(uses[0], TokenUse{At: start, Location: &paris}, 1000))
+	assert.False(t, DetectImpossibleTravel(uses[0], TokenUse{At: start, Location: &berlin}, 1000))
+
+	unresolved := TokenUse{IP: "192.0.2.1", At: start.Add(time.Minute)}
+	assert.Error(t, unresolved.Locate(context.Background(), resolve))
+	assert.False(t, DetectImpossibleTravel(uses[2], unresolved, 1000))
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidUserID = errors.New("invalid user id")
+
+// ParseUserID validates a user-supplied id before it reaches the repository.
+// The nil UUID is rejected as well.
+func ParseUserID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(strings.TrimSpace(s))
+	if err != nil || id == uuid.Nil {
+		return uuid.Nil, ErrInvalidUserID
+	}
+
+	return id, nil
+}
+
+// Redacted returns a log-safe representation of the user with the email
+// masked and the password hash omitted.
+func (u User) Redacted() string {
+	return fmt.Sprintf("User{ID: %s, Name: %s, Email: %s}", u.ID, u.Name, MaskEmail(u.Email))
+}
+
+// LogValue implements slog.LogValuer so that structured logs never contain
+// the raw email or the password hash.
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", u.ID.String()),
+		slog.String("name", u.Name),
+		slog.String("email", MaskEmail(u.Email)),
+	)
+}
+
+// MaskEmail keeps the first character of the local part and the domain,
+// e.g. "alice@example.com" becomes "a***@example.com".
+func MaskEmail(email string) string {
+	local, host, found := strings.Cut(email, "@")
+	if !found || local == "" {
+		return "***"
+	}
+
+	_, size := utf8.DecodeRuneInString(local)
+	return local[:size] + "***@" + host
+}
+
+// EmailHash returns the gravatar-compatible MD5 hex digest of the trimmed,
+// lowercased email, suitable for deriving an avatar URL.
+func (u User) EmailHash() string {
+	sum := md5.Sum([]byte(strings.ToLower(strings.TrimSpace(u.Email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemberSince returns when the account was created.
+func (u User) MemberSince() time.Time {
+	return u.CreatedAt
+}
+
+// AccountAge returns how long the account has existed at now. It is never
+// negative, even if CreatedAt is ahead of now due to clock skew.
+func (u User) AccountAge(now time.Time) time.Duration {
+	if now.Before(u.CreatedAt) {
+		return 0
+	}
+
+	return now.Sub(u.CreatedAt)
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_Redacted(t *testing.T) {
+	user := User{
+		ID:           uuid.New(),
+		Name:         "Alice",
+		Email:        "alice@example.com",
+		PasswordHash: "hashedpassword",
+	}
+
+	redacted := user.Redacted()
+	assert.Contains(t, redacted, "a***@example.com")
+	assert.NotContains(t, redacted, "alice@")
+	assert.NotContains(t, redacted, "hashedpassword")
+}
+
+func TestUser_LogValue(t *testing.T) {
+	user := User{
+		ID:           uuid.New(),
+		Name:         "Alice",
+		Email:        "alice@example.com",
+		PasswordHash: "hashedpassword",
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("user loaded", "user", user)
+
+	assert.Contains(t, buf.String(), "a***@example.com")
+	assert.NotContains(t, buf.String(), "alice@")
+	assert.NotContains(t, buf.String(), "hashedpassword")
+}
+
+func TestMaskEmail(t *testing.T) {
+	assert.Equal(t, "a***@example.com", MaskEmail("alice@example.com"))
+	assert.Equal(t, "é***@example.com", MaskEmail("élodie@example.com"))
+	assert.Equal(t, "***", MaskEmail("not-an-email"))
+	assert.Equal(t, "***", MaskEmail("@example.com"))
+}
+
+func TestUser_EmailHash(t *testing.T) {
+	// Reference value from the gravatar documentation
+	user := User{Email: " MyEmailAddress@example.com "}
+	assert.Equal(t, "0bc83cb571cd1c50ba6f3e8a78ef1346", user.EmailHash())
+}
+
+func TestParseUserID(t *testing.T) {
+	id := uuid.New()
+
+	parsed, err := ParseUserID(id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	for _, input := range []string{"", "not-a-uuid", "1234", id.String() + "0", "00000000-0000-0000-0000-000000000000"} {
+		_, err := ParseUserID(input)
+		assert.ErrorIs(t, err, ErrInvalidUserID, input)
+	}
+}
+
+func TestUser_AccountAge(t *testing.T) {
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	user := User{CreatedAt: createdAt}
+
+	assert.Equal(t, createdAt, user.MemberSince())
+	assert.Equal(t, 48*time.Hour, user.AccountAge(createdAt.Add(48*time.Hour)))
+	assert.Equal(t, time.Duration(0), user.AccountAge(createdAt))
+	assert.Equal(t, time.Duration(0), user.AccountAge(createdAt.Add(-time.Hour)))
+}
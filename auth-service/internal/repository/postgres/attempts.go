@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todoservice/auth-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// HourlyLoginStat counts the login attempts made within one hour.
+type HourlyLoginStat struct {
+	Hour      time.Time
+	Successes int64
+	Failures  int64
+}
+
+// SuccessRate returns the fraction of attempts in the hour that succeeded.
+func (s HourlyLoginStat) SuccessRate() float64 {
+	total := s.Successes + s.Failures
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.Successes) / float64(total)
+}
+
+// LoginAttemptDB stores successful and failed logins for the security panel.
+type LoginAttemptDB struct {
+	db   DBTX
+	opts options
+}
+
+func NewLoginAttemptDB(db DBTX, opts ...Option) *LoginAttemptDB {
+	o := newOptions(opts)
+
+	return &LoginAttemptDB{
+		db:   o.wrap(db),
+		opts: o,
+	}
+}
+
+// RecordAttempt stores a login attempt for the user. A zero CreatedAt is set
+// to now.
+func (l *LoginAttemptDB) RecordAttempt(ctx context.Context, attempt *domain.LoginAttempt) error {
+	ctx, cancel := l.opts.start(ctx, "LoginAttemptDB.RecordAttempt")
+	defer cancel()
+
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = l.opts.now()
+	}
+
+	query := `INSERT INTO login_attempts (user_id, success, ip, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := l.db.Exec(ctx, query, attempt.UserID, attempt.Success, attempt.IP, attempt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListRecentAttempts returns the user's latest login attempts, newest first.
+func (l *LoginAttemptDB) ListRecentAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.LoginAttempt, error) {
+	ctx, cancel := l.opts.start(ctx, "LoginAttemptDB.ListRecentAttempts")
+	defer cancel()
+
+	query := `SELECT user_id, success, COALESCE(ip, ''), created_at
+	          FROM login_attempts WHERE user_id = $1
+	          ORDER BY created_at DESC
+	          LIMIT $2`
+	rows, err := l.db.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*domain.LoginAttempt
+	for rows.Next() {
+		var attempt domain.LoginAttempt
+		if err := rows.Scan(&attempt.UserID, &attempt.Success, &attempt.IP, &attempt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan login attempt: %w", err)
+		}
+		attempts = append(attempts, &attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate login attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// LoginStatsByHour counts successful and failed attempts made in [from, to)
+// per hour, oldest first. Hours without attempts are omitted.
+func (l *LoginAttemptDB) LoginStatsByHour(ctx context.Context, from, to time.Time) ([]HourlyLoginStat, error) {
+	ctx, cancel := l.opts.start(ctx, "LoginAttemptDB.LoginStatsByHour")
+	defer cancel()
+
+	query := `SELECT date_trunc('hour', created_at) AS hour,
+	                 count(*) FILTER (WHERE success),
+	                 count(*) FILTER (WHERE NOT success)
+	          FROM login_attempts
+	          WHERE created_at >= $1 AND created_at < $2
+	          GROUP BY hour
+	          ORDER BY hour`
+	rows, err := l.db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute login stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []HourlyLoginStat
+	for rows.Next() {
+		var stat HourlyLoginStat
+		if err := rows.Scan(&stat.Hour, &stat.Successes, &stat.Failures); err != nil {
+			return nil, fmt.Errorf("failed to scan login stats: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate login stats: %w", err)
+	}
+
+	return stats, nil
+}
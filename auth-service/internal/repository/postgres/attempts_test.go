@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"todoservice/auth-service/internal/domain"
+)
+
+func TestLoginAttemptDB_ListRecentAttempts(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	attemptDB := NewLoginAttemptDB(conn)
+
+	userID := uuid.New()
+	now := time.Now()
+	attempts := []*domain.LoginAttempt{
+		{UserID: userID, Success: false, IP: "203.0.113.7", CreatedAt: now.Add(-3 * time.Minute)},
+		{UserID: userID, Success: false, IP: "203.0.113.7", CreatedAt: now.Add(-2 * time.Minute)},
+		{UserID: userID, Success: true, IP: "198.51.100.1", CreatedAt: now.Add(-time.Minute)},
+		{UserID: uuid.New(), Success: false, IP: "192.0.2.10", CreatedAt: now},
+	}
+	for _, attempt := range attempts {
+		assert.NoError(t, attemptDB.RecordAttempt(context.Background(), attempt))
+	}
+
+	recent, err := attemptDB.ListRecentAttempts(context.Background(), userID, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, recent, 3) {
+		assert.True(t, recent[0].Success)
+		assert.Equal(t, "198.51.100.1", recent[0].IP)
+		assert.False(t, recent[1].Success)
+		assert.False(t, recent[2].Success)
+		assert.True(t, recent[1].CreatedAt.After(recent[2].CreatedAt))
+	}
+
+	recent, err = attemptDB.ListRecentAttempts(context.Background(), userID, 2)
+	assert.NoError(t, err)
+	assert.Len(t, recent, 2)
+}
+
+func TestLoginAttemptDB_LoginStatsByHour(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	attemptDB := NewLoginAttemptDB(conn)
+
+	base := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration, success bool) *domain.LoginAttempt {
+		return &domain.LoginAttempt{UserID: uuid.New(), Success: success, CreatedAt: base.Add(offset)}
+	}
+	attempts := []*domain.LoginAttempt{
+		at(-time.Minute, true), // before the range
+		at(5*time.Minute, true),
+		at(20*time.Minute, true),
+		at(40*time.Minute, true),
+		at(59*time.Minute, false),
+		at(time.Hour, false),
+		at(90*time.Minute, false),
+		at(3*time.Hour+time.Minute, true),
+		at(4*time.Hour, true), // at the exclusive end
+	}
+	for _, attempt := range attempts {
+		assert.NoError(t, attemptDB.RecordAttempt(context.Background(), attempt))
+	}
+
+	stats, err := attemptDB.LoginStatsByHour(context.Background(), base, base.Add(4*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []HourlyLoginStat{
+		{Hour: base, Successes: 3, Failures: 1},
+		{Hour: base.Add(time.Hour), Successes: 0, Failures: 2},
+		{Hour: base.Add(3 * time.Hour), Successes: 1, Failures: 0},
+	}, stats)
+
+	assert.InDelta(t, 0.75, stats[0].SuccessRate(), 0.001)
+	assert.Zero(t, stats[1].SuccessRate())
+	assert.Equal(t, 1.0, stats[2].SuccessRate())
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// StartCleanupWorker runs DeleteExpired every interval in the background
+// until ctx is cancelled. Results are logged with the logger set through
+// WithLogger, or slog's default logger. The returned channel is closed once
+// the worker has stopped.
+func (r *RefreshTokenDB) StartCleanupWorker(ctx context.Context, interval time.Duration) <-chan struct{} {
+	logger := r.opts.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				deleted, err := r.DeleteExpired(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						logger.ErrorContext(ctx, "refresh token cleanup failed", "error", err)
+					}
+					continue
+				}
+				logger.InfoContext(ctx, "refresh token cleanup", "deleted", deleted)
+			}
+		}
+	}()
+
+	return done
+}
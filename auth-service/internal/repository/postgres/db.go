@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DBTX is the subset of pgx shared by *pgxpool.Pool, *pgx.Conn and pgx.Tx, so
+// the repositories can run on the pool or inside a caller's transaction. In
+// the service it should be a *pgxpool.Pool: a single *pgx.Conn is not safe
+// for concurrent use.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// txBeginner is implemented by the DBTX values that can start a transaction
+// with options, i.e. *pgxpool.Pool and *pgx.Conn but not pgx.Tx.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// beginTx begins a transaction on db with txOptions. Inside a transaction
+// the options cannot apply, so a savepoint is started instead.
+func beginTx(ctx context.Context, db DBTX, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	if b, ok := db.(txBeginner); ok {
+		return b.BeginTx(ctx, txOptions)
+	}
+
+	return db.Begin(ctx)
+}
+
+// inTx reports whether db, once the debug and metrics layers are peeled off,
+// is a transaction.
+func inTx(db DBTX) bool {
+	for {
+		switch d := db.(type) {
+		case pgx.Tx:
+			return true
+		case debugConn:
+			db = d.DBTX
+		case observedConn:
+			db = d.DBTX
+		default:
+			return false
+		}
+	}
+}
+
+// readSnapshot returns a DBTX whose reads all see the same snapshot and a
+// func releasing it. Outside a transaction it begins a read-only REPEATABLE
+// READ one. A caller's transaction, e.g. from TxManager.WithTx, is used as is
+// since its isolation level can no longer be changed.
+func readSnapshot(ctx context.Context, db DBTX) (DBTX, func(), error) {
+	if inTx(db) {
+		return db, func() {}, nil
+	}
+
+	tx, err := beginTx(ctx, db, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	return tx, func() { tx.Rollback(ctx) }, nil
+}
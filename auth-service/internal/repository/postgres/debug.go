@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// secretColumns lists the columns whose bound values must never be logged.
+var secretColumns = map[string]bool{
+	"password_hash": true,
+	"refresh_token": true,
+}
+
+var (
+	assignmentPattern = regexp.MustCompile(`(\w+)\s*=\s*\$(\d+)`)
+	insertPattern     = regexp.MustCompile(`(?is)INSERT\s+INTO\s+\w+\s*\(([^)]*)\)\s*VALUES\s*\(([^)]*)\)`)
+)
+
+// debugConn logs every statement and its arguments before executing it.
+type debugConn struct {
+	DBTX
+	logger *slog.Logger
+}
+
+func (d debugConn) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	d.log(ctx, sql, arguments)
+	return d.DBTX.Exec(ctx, sql, arguments...)
+}
+
+func (d debugConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	d.log(ctx, sql, args)
+	return d.DBTX.Query(ctx, sql, args...)
+}
+
+func (d debugConn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	d.log(ctx, sql, args)
+	return d.DBTX.QueryRow(ctx, sql, args...)
+}
+
+// Begin starts a transaction whose statements are logged and masked the same
+// way.
+func (d debugConn) Begin(ctx context.Context) (pgx.Tx, error) {
+	return d.BeginTx(ctx, pgx.TxOptions{})
+}
+
+func (d debugConn) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := beginTx(ctx, d.DBTX, txOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return debugTx{Tx: tx, conn: debugConn{DBTX: tx, logger: d.logger}}, nil
+}
+
+// debugTx routes the statements of a transaction begun on a debugConn
+// through that connection's logging.
+type debugTx struct {
+	pgx.Tx
+	conn debugConn
+}
+
+func (t debugTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return t.conn.Begin(ctx)
+}
+
+func (t debugTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return t.conn.Exec(ctx, sql, arguments...)
+}
+
+func (t debugTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return t.conn.Query(ctx, sql, args...)
+}
+
+func (t debugTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return t.conn.QueryRow(ctx, sql, args...)
+}
+
+func (d debugConn) log(ctx context.Context, sql string, args []any) {
+	d.logger.DebugContext(ctx, "executing query", "sql", sql, "args", maskSecretArgs(sql, args))
+}
+
+// maskSecretArgs returns a copy of args where values bound to secret columns
+// are replaced by a placeholder.
+func maskSecretArgs(sql string, args []any) []any {
+	masked := make([]any, len(args))
+	copy(masked, args)
+
+	mask := func(column, placeholder string) {
+		if !secretColumns[strings.ToLower(strings.TrimSpace(column))] {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(placeholder), "$"))
+		if err != nil || n < 1 || n > len(masked) {
+			return
+		}
+		masked[n-1] = "***"
+	}
+
+	for _, match := range assignmentPattern.FindAllStringSubmatch(sql, -1) {
+		mask(match[1], match[2])
+	}
+	for _, match := range insertPattern.FindAllStringSubmatch(sql, -1) {
+		columns := strings.Split(match[1], ",")
+		values := strings.Split(match[2], ",")
+		for i := 0; i < len(columns) && i < len(values); i++ {
+			mask(columns[i], values[i])
+		}
+	}
+
+	return masked
+}
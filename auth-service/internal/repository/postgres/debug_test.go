@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"todoservice/auth-service/internal/domain"
+)
+
+func TestMaskSecretArgs(t *testing.T) {
+	insert := `INSERT INTO users (id, name, email, password_hash, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6)`
+	masked := maskSecretArgs(insert, []any{"id", "Alice", "alice@example.com", "hashedpassword", "now", "now"})
+	assert.Equal(t, []any{"id", "Alice", "alice@example.com", "***", "now", "now"}, masked)
+
+	lookup := `SELECT id FROM refresh_tokens WHERE refresh_token=$1 AND user_id = $2`
+	masked = maskSecretArgs(lookup, []any{"example_refresh_token", "user"})
+	assert.Equal(t, []any{"***", "user"}, masked)
+}
+
+func TestUserDB_DebugSQL(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	userDB := NewUserDB(conn, WithDebugSQL(logger))
+
+	_, err := userDB.ReadByEmail(context.Background(), "alice@example.com")
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "FROM users WHERE email=$1")
+	assert.Contains(t, buf.String(), "alice@example.com")
+
+	buf.Reset()
+	err = userDB.Create(context.Background(), &domain.User{
+		Name:         "Alice",
+		Email:        "alice@example.com",
+		PasswordHash: "hashedpassword",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "INSERT INTO users (id, name, email, password_hash, phone, created_at, updated_at, email_normalized, email_domain, hash_algorithm)")
+	assert.Contains(t, buf.String(), "***")
+	assert.NotContains(t, buf.String(), "hashedpassword")
+
+	// Statements run inside a transaction are logged and masked too
+	buf.Reset()
+	err = userDB.CreateBatch(context.Background(), uuid.New(), []*domain.User{
+		{Name: "Bob", Email: "bob@example.com", PasswordHash: "batchhashedpassword"},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "import_batch_id")
+	assert.Contains(t, buf.String(), "bob@example.com")
+	assert.NotContains(t, buf.String(), "batchhashedpassword")
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"sync"
+	"time"
+)
+
+const errorRateBuckets = 10
+
+type errorRateBucket struct {
+	index  int64
+	total  int64
+	errors int64
+}
+
+// ErrorRates is a Metrics implementation keeping per-op success and error
+// counts over a rolling window, split into fixed-width buckets so old
+// observations age out without storing each one.
+type ErrorRates struct {
+	mu      sync.Mutex
+	width   time.Duration
+	buckets map[string]*[errorRateBuckets]errorRateBucket
+	now     func() time.Time
+}
+
+// NewErrorRates tracks error rates over the given window.
+func NewErrorRates(window time.Duration) *ErrorRates {
+	width := window / errorRateBuckets
+	if width <= 0 {
+		width = 1
+	}
+
+	return &ErrorRates{
+		width:   width,
+		buckets: make(map[string]*[errorRateBuckets]errorRateBucket),
+		now:     time.Now,
+	}
+}
+
+// ObserveQuery implements Metrics.
+func (e *ErrorRates) ObserveQuery(op string, _ time.Duration, err error) {
+	index := e.now().UnixNano() / int64(e.width)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	buckets, ok := e.buckets[op]
+	if !ok {
+		buckets = &[errorRateBuckets]errorRateBucket{}
+		e.buckets[op] = buckets
+	}
+
+	bucket := &buckets[index%errorRateBuckets]
+	if bucket.index != index {
+		*bucket = errorRateBucket{index: index}
+	}
+	bucket.total++
+	if err != nil {
+		bucket.errors++
+	}
+}
+
+// ErrorRate returns the fraction of failed statements of op within the
+// window, or 0 when op has not run in it.
+func (e *ErrorRates) ErrorRate(op string) float64 {
+	index := e.now().UnixNano() / int64(e.width)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	buckets, ok := e.buckets[op]
+	if !ok {
+		return 0
+	}
+
+	var total, errors int64
+	for _, bucket := range buckets {
+		if bucket.index > index-errorRateBuckets {
+			total += bucket.total
+			errors += bucket.errors
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	return float64(errors) / float64(total)
+}
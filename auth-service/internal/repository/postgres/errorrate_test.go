@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorRates(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+	rates := NewErrorRates(time.Minute)
+	rates.now = func() time.Time { return now }
+
+	assert.Zero(t, rates.ErrorRate("UserDB.Read"))
+
+	failure := errors.New("boom")
+	for i := 0; i < 3; i++ {
+		rates.ObserveQuery("UserDB.Read", time.Millisecond, nil)
+	}
+	rates.ObserveQuery("UserDB.Read", time.Millisecond, failure)
+	rates.ObserveQuery("UserDB.Create", time.Millisecond, failure)
+
+	assert.InDelta(t, 0.25, rates.ErrorRate("UserDB.Read"), 0.001)
+	assert.InDelta(t, 1, rates.ErrorRate("UserDB.Create"), 0.001)
+
+	now = now.Add(30 * time.Second)
+	rates.ObserveQuery("UserDB.Read", time.Millisecond, failure)
+	assert.InDelta(t, 0.4, rates.ErrorRate("UserDB.Read"), 0.001)
+
+	// The first observations age out of the window
+	now = now.Add(45 * time.Second)
+	assert.InDelta(t, 1, rates.ErrorRate("UserDB.Read"), 0.001)
+	assert.Zero(t, rates.ErrorRate("UserDB.Create"))
+}
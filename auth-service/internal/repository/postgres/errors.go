@@ -0,0 +1,31 @@
+package postgres
+
+import "errors"
+
+// Errors returned by the repositories. Lookups that find no row wrap
+// ErrUserNotFound or ErrRefreshTokenNotFound together with the underlying pgx
+// error, so callers can match them with errors.Is.
+var (
+	ErrUserNotFound         = errors.New("user not found")
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	// ErrEmailAlreadyExists is returned when an insert or update would
+	// duplicate another user's email.
+	ErrEmailAlreadyExists = errors.New("email already exists")
+	ErrSeatLimitReached   = errors.New("seat limit reached")
+	// ErrUserHasActiveSessions is returned by DeleteIfNoActiveSessions for a
+	// user who is still logged in somewhere.
+	ErrUserHasActiveSessions = errors.New("user has active sessions")
+	// ErrRefreshRace is returned by ClaimForRotation when another caller has
+	// already claimed the token.
+	ErrRefreshRace = errors.New("refresh token already claimed for rotation")
+	// Invite redemption errors returned by InviteDB.RedeemInvite.
+	ErrInviteNotFound = errors.New("invite code not found")
+	ErrInviteExpired  = errors.New("invite code expired")
+	ErrInviteUsed     = errors.New("invite code already used")
+	// GetSession errors for a token that exists but can no longer be used.
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	// ErrTokenOverused is returned by RecordUse once a token has been
+	// presented more often than the configured maximum.
+	ErrTokenOverused = errors.New("refresh token used too many times")
+)
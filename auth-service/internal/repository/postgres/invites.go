@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// InviteDB redeems invite codes for invite-only deployments.
+type InviteDB struct {
+	db   DBTX
+	opts options
+}
+
+// NewInviteDB returns an InviteDB on db. Pass the registration transaction so
+// that a failed registration does not consume the code.
+func NewInviteDB(db DBTX, opts ...Option) *InviteDB {
+	o := newOptions(opts)
+
+	return &InviteDB{
+		db:   o.wrap(db),
+		opts: o,
+	}
+}
+
+// RedeemInvite atomically marks an unused, unexpired code as used and returns
+// its id. Otherwise it returns ErrInviteNotFound, ErrInviteExpired or
+// ErrInviteUsed.
+func (i *InviteDB) RedeemInvite(ctx context.Context, code string) (uuid.UUID, error) {
+	ctx, cancel := i.opts.start(ctx, "InviteDB.RedeemInvite")
+	defer cancel()
+
+	now := i.opts.now()
+
+	query := `UPDATE invite_codes SET used_at = $2
+	          WHERE code = $1 AND used_at IS NULL AND expires_at > $2
+	          RETURNING id`
+
+	var id uuid.UUID
+	err := i.db.QueryRow(ctx, query, code, now).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("failed to redeem invite: %w", err)
+	}
+
+	// Nothing was updated: report why
+	var expiresAt time.Time
+	var used bool
+	query = `SELECT expires_at, used_at IS NOT NULL FROM invite_codes WHERE code = $1`
+	err = i.db.QueryRow(ctx, query, code).Scan(&expiresAt, &used)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("%w: %w", ErrInviteNotFound, err)
+		}
+		return uuid.Nil, fmt.Errorf("failed to read invite: %w", err)
+	}
+	if used {
+		return uuid.Nil, ErrInviteUsed
+	}
+
+	return uuid.Nil, ErrInviteExpired
+}
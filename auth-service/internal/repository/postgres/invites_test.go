@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"todoservice/auth-service/internal/domain"
+)
+
+func TestInviteDB_RedeemInvite(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	validID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO invite_codes (id, code, expires_at) VALUES ($1, 'valid', $3), ($2, 'expired', $4)`,
+		validID, uuid.New(), time.Now().Add(time.Hour), time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+
+	inviteDB := NewInviteDB(conn)
+
+	id, err := inviteDB.RedeemInvite(context.Background(), "valid")
+	assert.NoError(t, err)
+	assert.Equal(t, validID, id)
+
+	_, err = inviteDB.RedeemInvite(context.Background(), "valid")
+	assert.ErrorIs(t, err, ErrInviteUsed)
+
+	_, err = inviteDB.RedeemInvite(context.Background(), "expired")
+	assert.ErrorIs(t, err, ErrInviteExpired)
+
+	_, err = inviteDB.RedeemInvite(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrInviteNotFound)
+}
+
+func TestInviteDB_RedeemInviteRolledBack(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	_, err := conn.Exec(context.Background(), `INSERT INTO invite_codes (id, code, expires_at) VALUES ($1, 'invite', $2)`,
+		uuid.New(), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	// Registration fails after the code was redeemed, e.g. on a taken email
+	err = NewUserDB(conn).Create(context.Background(), &domain.User{Name: "Alice", Email: "alice@example.com", PasswordHash: "hashedpassword"})
+	assert.NoError(t, err)
+
+	tx, err := conn.Begin(context.Background())
+	assert.NoError(t, err)
+	_, err = NewInviteDB(tx).RedeemInvite(context.Background(), "invite")
+	assert.NoError(t, err)
+	err = NewUserDB(tx).Create(context.Background(), &domain.User{Name: "Alice Again", Email: "alice@example.com", PasswordHash: "hashedpassword"})
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+	assert.NoError(t, tx.Rollback(context.Background()))
+
+	// The code is still available
+	_, err = NewInviteDB(conn).RedeemInvite(context.Background(), "invite")
+	assert.NoError(t, err)
+}
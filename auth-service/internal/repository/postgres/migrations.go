@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSchemaMismatch is returned by CheckSchemaCompatible when the database is
+// not at the schema version the service was built for.
+var ErrSchemaMismatch = errors.New("database schema version mismatch")
+
+// SchemaVersion returns the version recorded in schema_migrations, in the
+// layout used by golang-migrate. A database with no recorded migration is at
+// version 0; one left dirty by a failed migration is an error.
+func SchemaVersion(ctx context.Context, db DBTX) (int, error) {
+	query := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+	var version int
+	var dirty bool
+	err := db.QueryRow(ctx, query).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if dirty {
+		return version, fmt.Errorf("schema version %d is dirty, a migration failed halfway", version)
+	}
+
+	return version, nil
+}
+
+// CheckSchemaCompatible fails unless the database is exactly at expected, so
+// the service refuses to start against an unmigrated or newer schema.
+func CheckSchemaCompatible(ctx context.Context, db DBTX, expected int) error {
+	version, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version < expected:
+		return fmt.Errorf("%w: database is at version %d, expected %d; run the pending migrations", ErrSchemaMismatch, version, expected)
+	case version > expected:
+		return fmt.Errorf("%w: database is at version %d, newer than the expected %d; deploy a matching service build", ErrSchemaMismatch, version, expected)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSchemaCompatible(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	_, err := conn.Exec(context.Background(), `CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	assert.NoError(t, err)
+
+	version, err := SchemaVersion(context.Background(), conn)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	_, err = conn.Exec(context.Background(), `INSERT INTO schema_migrations (version, dirty) VALUES (5, false)`)
+	assert.NoError(t, err)
+
+	version, err = SchemaVersion(context.Background(), conn)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, version)
+
+	assert.NoError(t, CheckSchemaCompatible(context.Background(), conn, 5))
+
+	// The database is behind the service
+	err = CheckSchemaCompatible(context.Background(), conn, 6)
+	assert.ErrorIs(t, err, ErrSchemaMismatch)
+	assert.Contains(t, err.Error(), "pending migrations")
+
+	// The database is ahead of the service
+	err = CheckSchemaCompatible(context.Background(), conn, 4)
+	assert.ErrorIs(t, err, ErrSchemaMismatch)
+	assert.Contains(t, err.Error(), "newer")
+
+	_, err = conn.Exec(context.Background(), `UPDATE schema_migrations SET dirty = true`)
+	assert.NoError(t, err)
+
+	err = CheckSchemaCompatible(context.Background(), conn, 5)
+	assert.Error(t, err)
+}
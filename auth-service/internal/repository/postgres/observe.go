@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Metrics receives the outcome of every statement run by the repositories.
+// The op label is the caller's operation tag, or the repository method that
+// issued the statement when no tag was set.
+type Metrics interface {
+	ObserveQuery(op string, duration time.Duration, err error)
+}
+
+// PoolWaitObserver can be implemented by Metrics to also receive the time a
+// statement waited for a pooled connection. That wait is then excluded from
+// the duration passed to ObserveQuery, so a saturated pool is not mistaken
+// for slow queries.
+type PoolWaitObserver interface {
+	ObservePoolWait(op string, wait time.Duration)
+}
+
+type opKey struct{}
+
+type tagKey struct{}
+
+// WithOperationTag attaches a short tag (e.g. "login", "profile-load") to the
+// context. Repository calls made with it report the tag instead of their
+// method name in metrics and logs, so slow queries can be traced back to the
+// feature that issued them.
+func WithOperationTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, tagKey{}, tag)
+}
+
+// withOp records the operation label of a repository call: the caller's tag
+// when present, the method name otherwise.
+func withOp(ctx context.Context, method string) context.Context {
+	if tag, _ := ctx.Value(tagKey{}).(string); tag != "" {
+		return context.WithValue(ctx, opKey{}, tag)
+	}
+
+	return context.WithValue(ctx, opKey{}, method)
+}
+
+func opFromContext(ctx context.Context) string {
+	op, _ := ctx.Value(opKey{}).(string)
+	return op
+}
+
+// observedConn reports the duration and outcome of every statement to the
+// configured metrics and logs failures.
+type observedConn struct {
+	DBTX
+	metrics Metrics
+	logger  *slog.Logger
+}
+
+func (o observedConn) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	db, release, err := o.acquire(ctx)
+	if err != nil {
+		o.observe(ctx, time.Now(), err)
+		return pgconn.CommandTag{}, err
+	}
+	defer release()
+
+	start := time.Now()
+	tag, err := db.Exec(ctx, sql, arguments...)
+	o.observe(ctx, start, err)
+
+	return tag, err
+}
+
+func (o observedConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	db, release, err := o.acquire(ctx)
+	if err != nil {
+		o.observe(ctx, time.Now(), err)
+		return nil, err
+	}
+
+	start := time.Now()
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		release()
+		o.observe(ctx, start, err)
+		return nil, err
+	}
+
+	return &observedRows{Rows: rows, done: func(err error) {
+		o.observe(ctx, start, err)
+		release()
+	}}, nil
+}
+
+func (o observedConn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	db, release, err := o.acquire(ctx)
+	if err != nil {
+		o.observe(ctx, time.Now(), err)
+		return errRow{err: err}
+	}
+
+	start := time.Now()
+	row := db.QueryRow(ctx, sql, args...)
+
+	return observedRow{row: row, done: func(err error) {
+		o.observe(ctx, start, err)
+		release()
+	}}
+}
+
+// Begin starts a transaction whose statements are reported the same way.
+func (o observedConn) Begin(ctx context.Context) (pgx.Tx, error) {
+	return o.BeginTx(ctx, pgx.TxOptions{})
+}
+
+func (o observedConn) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := beginTx(ctx, o.DBTX, txOptions)
+	if err != nil {
+		o.observe(ctx, time.Now(), err)
+		return nil, err
+	}
+
+	return observedTx{Tx: tx, conn: observedConn{DBTX: tx, metrics: o.metrics, logger: o.logger}}, nil
+}
+
+// observedTx routes the statements of a transaction begun on an observedConn
+// through that connection's metrics and logging.
+type observedTx struct {
+	pgx.Tx
+	conn observedConn
+}
+
+func (t observedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return t.conn.Begin(ctx)
+}
+
+func (t observedTx) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return t.conn.Exec(ctx, sql, arguments...)
+}
+
+func (t observedTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return t.conn.Query(ctx, sql, args...)
+}
+
+func (t observedTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return t.conn.QueryRow(ctx, sql, args...)
+}
+
+// acquire checks a connection out of the pool ahead of the statement when the
+// metrics observe pool waits, reporting how long that took. Otherwise, or
+// when not running on a pool, the wrapped DBTX is used as is.
+func (o observedConn) acquire(ctx context.Context) (DBTX, func(), error) {
+	pool, isPool := o.DBTX.(*pgxpool.Pool)
+	waits, observesWaits := o.metrics.(PoolWaitObserver)
+	if !isPool || !observesWaits {
+		return o.DBTX, func() {}, nil
+	}
+
+	start := time.Now()
+	conn, err := pool.Acquire(ctx)
+	waits.ObservePoolWait(opFromContext(ctx), time.Since(start))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conn, conn.Release, nil
+}
+
+func (o observedConn) observe(ctx context.Context, start time.Time, err error) {
+	op := opFromContext(ctx)
+	if o.metrics != nil {
+		o.metrics.ObserveQuery(op, time.Since(start), err)
+	}
+	if err != nil && o.logger != nil {
+		o.logger.WarnContext(ctx, "query failed", "op", op, "error", err)
+	}
+}
+
+// errRow is returned by QueryRow when no connection could be acquired.
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
+
+type observedRow struct {
+	row  pgx.Row
+	done func(error)
+}
+
+func (r observedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	// A missing row is a regular outcome rather than a failed query.
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.done(nil)
+	} else {
+		r.done(err)
+	}
+
+	return err
+}
+
+type observedRows struct {
+	pgx.Rows
+	done   func(error)
+	closed bool
+}
+
+func (r *observedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.finish()
+
+	return false
+}
+
+func (r *observedRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
+
+func (r *observedRows) finish() {
+	if r.closed {
+		return
+	}
+	r.closed = true
+	r.done(r.Rows.Err())
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+type poolWaitMetrics struct {
+	mu       sync.Mutex
+	query    map[string]time.Duration
+	poolWait map[string]time.Duration
+}
+
+func (m *poolWaitMetrics) ObserveQuery(op string, duration time.Duration, _ error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.query[op] += duration
+}
+
+func (m *poolWaitMetrics) ObservePoolWait(op string, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.poolWait[op] += wait
+}
+
+func TestObservedConn_PoolWait(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "metrics only"},
+		{name: "with debug SQL", opts: []Option{WithDebugSQL(slog.New(slog.NewTextHandler(io.Discard, nil)))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := conn.Config()
+			config.MaxConns = 1
+			pool, err := pgxpool.NewWithConfig(context.Background(), config)
+			assert.NoError(t, err)
+			defer pool.Close()
+
+			metrics := &poolWaitMetrics{query: make(map[string]time.Duration), poolWait: make(map[string]time.Duration)}
+			userDB := NewUserDB(pool, append(tt.opts, WithMetrics(metrics))...)
+
+			// Hold the only connection so the read has to wait for it
+			held, err := pool.Acquire(context.Background())
+			assert.NoError(t, err)
+			go func() {
+				time.Sleep(300 * time.Millisecond)
+				held.Release()
+			}()
+
+			_, err = userDB.Read(context.Background(), uuid.New())
+			assert.ErrorIs(t, err, ErrUserNotFound)
+
+			_, err = userDB.ListRecent(context.Background(), 10)
+			assert.NoError(t, err)
+
+			metrics.mu.Lock()
+			defer metrics.mu.Unlock()
+			assert.GreaterOrEqual(t, metrics.poolWait["UserDB.Read"], 250*time.Millisecond)
+			assert.Less(t, metrics.query["UserDB.Read"], 250*time.Millisecond)
+			// The connection is released after each statement
+			assert.Less(t, metrics.poolWait["UserDB.ListRecent"], 250*time.Millisecond)
+			assert.Contains(t, metrics.query, "UserDB.ListRecent")
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+	"todoservice/auth-service/internal/domain"
+)
+
+// Option configures the postgres repositories.
+type Option func(*options)
+
+type options struct {
+	normalizeOnScan bool
+	debugLogger     *slog.Logger
+	logger          *slog.Logger
+	metrics         Metrics
+	clock           func() time.Time
+	timeout         time.Duration
+	seatLimit       int
+	maxTokenUses    int
+	foldConfusables bool
+	syncCommit      bool
+	hashAlgorithm   string
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithLogger sets the logger used to report failed queries.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithMetrics reports the duration and outcome of every query.
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+// WithClock overrides the time source used for created_at/updated_at and for
+// expiry checks. Methods taking an explicit now, e.g. TokenStatus or
+// RecordUse, compare against that instead. Mostly useful in tests.
+func WithClock(clock func() time.Time) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithTimeout bounds every repository call by the given duration.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithSeatLimit caps the total number of users UserDB.Create and the batch
+// creates will allow. Zero means unlimited.
+func WithSeatLimit(limit int) Option {
+	return func(o *options) {
+		o.seatLimit = limit
+	}
+}
+
+// WithMaxTokenUses makes RefreshTokenDB.RecordUse reject a token presented
+// more than max times. Zero means unlimited.
+func WithMaxTokenUses(max int) Option {
+	return func(o *options) {
+		o.maxTokenUses = max
+	}
+}
+
+// WithConfusableFolding folds Cyrillic and Greek look-alike letters into
+// their Latin counterparts when computing email_normalized, so visually
+// identical emails collide on its unique index.
+func WithConfusableFolding() Option {
+	return func(o *options) {
+		o.foldConfusables = true
+	}
+}
+
+// WithSynchronousCommit makes TxManager transactions run with
+// synchronous_commit on, overriding a relaxed server setting, so a successful
+// commit means the writes are durable.
+func WithSynchronousCommit() Option {
+	return func(o *options) {
+		o.syncCommit = true
+	}
+}
+
+// WithHashAlgorithm records algorithm, typically PasswordHasher.Algorithm, in
+// hash_algorithm on every write that sets password_hash. Without it the column
+// is left NULL, which ListHashesNeedingUpgrade treats as outdated.
+func WithHashAlgorithm(algorithm string) Option {
+	return func(o *options) {
+		o.hashAlgorithm = algorithm
+	}
+}
+
+// WithScanNormalization lowercases and trims emails and trims names when users
+// are read back, so callers see consistent values even for historical rows.
+// By default the stored values are returned unchanged.
+func WithScanNormalization() Option {
+	return func(o *options) {
+		o.normalizeOnScan = true
+	}
+}
+
+// WithDebugSQL logs every parameterized statement and its arguments at debug
+// level before it is executed. Password hashes and refresh token values are
+// masked.
+func WithDebugSQL(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.debugLogger = logger
+	}
+}
+
+// wrap layers the debug and metrics decorators over db. observedConn goes
+// directly on db so it still sees the *pgxpool.Pool when reporting pool waits.
+func (o options) wrap(db DBTX) DBTX {
+	switch db.(type) {
+	case debugTx, observedTx:
+		// Begun on a DBTX that is wrapped already, e.g. by TxManager
+		return db
+	}
+	if o.metrics != nil || o.logger != nil {
+		db = observedConn{DBTX: db, metrics: o.metrics, logger: o.logger}
+	}
+	if o.debugLogger != nil {
+		db = debugConn{DBTX: db, logger: o.debugLogger}
+	}
+
+	return db
+}
+
+func (o options) now() time.Time {
+	if o.clock != nil {
+		return o.clock()
+	}
+
+	return time.Now()
+}
+
+// start prepares the context of a repository call: it records the operation
+// label for metrics and applies the configured timeout.
+func (o options) start(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	ctx = withOp(ctx, method)
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+// emailKey returns the email_normalized value for email.
+func (o options) emailKey(email string) string {
+	return domain.EmailLookupKey(email, o.foldConfusables)
+}
+
+// hashAlgo returns the hash_algorithm value stored alongside a new password
+// hash, or nil when no algorithm is configured.
+func (o options) hashAlgo() any {
+	if o.hashAlgorithm == "" {
+		return nil
+	}
+
+	return o.hashAlgorithm
+}
+
+func (o options) normalizeUsers(users ...*domain.User) {
+	if !o.normalizeOnScan {
+		return
+	}
+
+	for _, user := range users {
+		user.Email = domain.NormalizeEmail(user.Email)
+		user.Name = strings.TrimSpace(user.Name)
+	}
+}
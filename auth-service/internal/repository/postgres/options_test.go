@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"todoservice/auth-service/internal/domain"
+)
+
+type recordingMetrics struct {
+	mu     sync.Mutex
+	ops    []string
+	errors int
+}
+
+func (m *recordingMetrics) ObserveQuery(op string, _ time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ops = append(m.ops, op)
+	if err != nil {
+		m.errors++
+	}
+}
+
+func TestNewUserDB_Options(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	fixed := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+	metrics := &recordingMetrics{}
+	userDB := NewUserDB(conn, WithClock(func() time.Time { return fixed }), WithMetrics(metrics))
+
+	user := &domain.User{
+		Name:         "Alice",
+		Email:        "alice@example.com",
+		PasswordHash: "hashedpassword",
+	}
+	err := userDB.Create(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, fixed, user.CreatedAt)
+	assert.Equal(t, fixed, user.UpdatedAt)
+
+	_, err = userDB.Read(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"UserDB.Create", "UserDB.Read"}, metrics.ops)
+	assert.Zero(t, metrics.errors)
+}
+
+func TestWithMetrics_Transaction(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	metrics := &recordingMetrics{}
+	userDB := NewUserDB(conn, WithMetrics(metrics))
+
+	err := userDB.CreateBatch(context.Background(), uuid.New(), []*domain.User{
+		{Name: "Alice", Email: "alice@example.com", PasswordHash: "hashedpassword"},
+		{Name: "Bob", Email: "bob@example.com", PasswordHash: "hashedpassword"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"UserDB.CreateBatch", "UserDB.CreateBatch"}, metrics.ops)
+	assert.Zero(t, metrics.errors)
+}
+
+func TestNewRefreshTokenDB_WithClock(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at) VALUES ($1, $2, $3, $4)`,
+		uuid.New(), uuid.New(), "token", now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	_, err = NewRefreshTokenDB(conn).ReadActiveByRefreshToken(context.Background(), "token")
+	assert.NoError(t, err)
+
+	later := NewRefreshTokenDB(conn, WithClock(func() time.Time { return now.Add(2 * time.Hour) }))
+	_, err = later.ReadActiveByRefreshToken(context.Background(), "token")
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+}
+
+func TestNewRefreshTokenDB_WithTimeout(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	tokenDB := NewRefreshTokenDB(conn, WithTimeout(time.Nanosecond))
+
+	_, err := tokenDB.Read(context.Background(), uuid.New())
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWithOperationTag(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	metrics := &recordingMetrics{}
+	userDB := NewUserDB(conn, WithMetrics(metrics))
+
+	_, err := userDB.ReadByEmail(WithOperationTag(context.Background(), "login"), "alice@example.com")
+	assert.Error(t, err)
+
+	_, err = userDB.ReadByEmail(context.Background(), "alice@example.com")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"login", "UserDB.ReadByEmail"}, metrics.ops)
+}
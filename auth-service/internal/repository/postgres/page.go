@@ -0,0 +1,8 @@
+package postgres
+
+// Page is a single page of results together with the total number of rows
+// matching the query.
+type Page[T any] struct {
+	Items []T
+	Total int64
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// errorRateWindow is the rolling window of PrometheusMetrics.ErrorRate.
+const errorRateWindow = 5 * time.Minute
+
+// PrometheusMetrics is a Metrics implementation exporting per-op query
+// counts, errors, latencies and connection pool waits as Prometheus
+// collectors.
+type PrometheusMetrics struct {
+	queries  *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	poolWait *prometheus.HistogramVec
+	rates    *ErrorRates
+}
+
+// NewPrometheusMetrics creates the collectors and registers them with reg.
+func NewPrometheusMetrics(reg prometheus.Registerer) (*PrometheusMetrics, error) {
+	m := &PrometheusMetrics{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_db_queries_total",
+			Help: "Number of database statements run by the auth repositories.",
+		}, []string{"op"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_db_query_errors_total",
+			Help: "Number of database statements that failed.",
+		}, []string{"op"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "auth_db_query_duration_seconds",
+			Help:    "Duration of database statements.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"op"}),
+		poolWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "auth_db_pool_wait_duration_seconds",
+			Help:    "Time statements waited for a pooled connection.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"op"}),
+		rates: NewErrorRates(errorRateWindow),
+	}
+
+	for _, c := range []prometheus.Collector{m.queries, m.errors, m.duration, m.poolWait} {
+		if err := reg.Register(c); err != nil {
+			return nil, fmt.Errorf("failed to register metrics: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// ObserveQuery implements Metrics.
+func (m *PrometheusMetrics) ObserveQuery(op string, duration time.Duration, err error) {
+	m.queries.WithLabelValues(op).Inc()
+	m.duration.WithLabelValues(op).Observe(duration.Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(op).Inc()
+	}
+	m.rates.ObserveQuery(op, duration, err)
+}
+
+// ObservePoolWait implements PoolWaitObserver.
+func (m *PrometheusMetrics) ObservePoolWait(op string, wait time.Duration) {
+	m.poolWait.WithLabelValues(op).Observe(wait.Seconds())
+}
+
+// ErrorRate returns the fraction of failed statements of op over the last
+// five minutes.
+func (m *PrometheusMetrics) ErrorRate(op string) float64 {
+	return m.rates.ErrorRate(op)
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"todoservice/auth-service/internal/domain"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	reg := prometheus.NewRegistry()
+	metrics, err := NewPrometheusMetrics(reg)
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn, WithMetrics(metrics))
+
+	err = userDB.Create(context.Background(), &domain.User{Name: "Alice", Email: "alice@example.com", PasswordHash: "hashedpassword"})
+	assert.NoError(t, err)
+	err = userDB.Create(context.Background(), &domain.User{Name: "Alice Again", Email: "alice@example.com", PasswordHash: "hashedpassword"})
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+	_, err = userDB.Read(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+
+	byName := make(map[string]*dto.MetricFamily)
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+
+	counterFor := func(name, op string) float64 {
+		family, ok := byName[name]
+		if !assert.True(t, ok, name) {
+			return 0
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "op" && label.GetValue() == op {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+		return 0
+	}
+
+	assert.Equal(t, float64(2), counterFor("auth_db_queries_total", "UserDB.Create"))
+	assert.Equal(t, float64(1), counterFor("auth_db_queries_total", "UserDB.Read"))
+	assert.Equal(t, float64(1), counterFor("auth_db_query_errors_total", "UserDB.Create"))
+	// A missing row is not a failed query
+	assert.Equal(t, float64(0), counterFor("auth_db_query_errors_total", "UserDB.Read"))
+	assert.Contains(t, byName, "auth_db_query_duration_seconds")
+	assert.InDelta(t, 0.5, metrics.ErrorRate("UserDB.Create"), 0.001)
+	assert.Zero(t, metrics.ErrorRate("UserDB.Read"))
+
+	// Registering twice on the same registry fails
+	_, err = NewPrometheusMetrics(reg)
+	assert.Error(t, err)
+}
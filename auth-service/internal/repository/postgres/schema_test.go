@@ -0,0 +1,44 @@
+package postgres
+
+// testSchema mirrors the production tables used by the repository tests.
+const testSchema = `
+	CREATE TABLE users (
+		id UUID PRIMARY KEY,
+		name VARCHAR(100),
+		email VARCHAR(100) UNIQUE,
+		email_normalized VARCHAR(100) UNIQUE,
+		email_domain VARCHAR(100),
+		password_hash VARCHAR(100),
+		hash_algorithm VARCHAR(32),
+		phone VARCHAR(16),
+		import_batch_id UUID,
+		password_changed_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX users_email_domain_idx ON users (email_domain);
+	CREATE TABLE refresh_tokens (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL,
+		refresh_token TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		revoked BOOLEAN NOT NULL DEFAULT false,
+		rotated_at TIMESTAMP,
+		family_id UUID,
+		use_count INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE login_attempts (
+		user_id UUID NOT NULL,
+		success BOOLEAN NOT NULL,
+		ip VARCHAR(45),
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE invite_codes (
+		id UUID PRIMARY KEY,
+		code TEXT NOT NULL UNIQUE,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP
+	);
+`
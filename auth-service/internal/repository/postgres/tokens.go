@@ -11,42 +11,152 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+// Tokens created before family_id existed form a family of their own.
+const refreshTokenColumns = `id, user_id, refresh_token, expires_at, revoked, use_count, COALESCE(family_id, id), rotated_at, created_at, updated_at`
+
+// TokenWithUser is a refresh token together with the owner's contact details,
+// used by the sessions admin view.
+type TokenWithUser struct {
+	domain.RefreshToken
+	UserEmail string
+	UserName  string
+}
+
+// TokenFilter narrows ListTokensWithUser. Zero values disable a criterion;
+// a non-positive Limit defaults to 50.
+type TokenFilter struct {
+	UserID   uuid.UUID
+	ActiveAt time.Time
+	Limit    int
+	Offset   int
+}
+
+// SessionStats summarises a user's active sessions. The creation times are
+// zero when the user has no active session.
+type SessionStats struct {
+	Active          int64
+	EarliestCreated time.Time
+	LatestCreated   time.Time
+}
+
 type RefreshTokenDB struct {
-	db *pgx.Conn
+	db   DBTX
+	opts options
 }
 
-func NewRefreshTokenDB(db *pgx.Conn) *RefreshTokenDB {
+func NewRefreshTokenDB(db DBTX, opts ...Option) *RefreshTokenDB {
+	o := newOptions(opts)
+
 	return &RefreshTokenDB{
-		db: db,
+		db:   o.wrap(db),
+		opts: o,
 	}
 }
 
+// Create inserts a new refresh token. Non-zero CreatedAt/UpdatedAt values
+// provided by the caller are preserved. A token without a FamilyID starts a
+// new family, as at login; rotation passes the predecessor's family on. The
+// user row is share-locked for the insert so that
+// UserDB.DeleteIfNoActiveSessions cannot miss the new token.
 func (r *RefreshTokenDB) Create(ctx context.Context, token *domain.RefreshToken) error {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.Create")
+	defer cancel()
+
+	now := r.opts.now()
 	token.ID = uuid.New()
-	token.CreatedAt = time.Now()
-	token.UpdatedAt = time.Now()
+	if token.FamilyID == uuid.Nil {
+		token.FamilyID = token.ID
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = now
+	}
+	if token.UpdatedAt.IsZero() {
+		token.UpdatedAt = now
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	query := `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at)
-              VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR SHARE`, token.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	query := `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, family_id, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
-	_, err := r.db.Exec(ctx, query, token.ID, token.UserID, token.RefreshToken, token.ExpiresAt, token.CreatedAt, token.UpdatedAt)
+	_, err = tx.Exec(ctx, query, token.ID, token.UserID, token.RefreshToken, token.ExpiresAt, token.FamilyID, token.CreatedAt, token.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to insert refresh token: %w", err)
 	}
 
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
+// UpsertBatch inserts the tokens with their existing ids, skipping any id that
+// is already stored, so replaying the same tokens is idempotent. It returns
+// how many tokens were newly inserted.
+func (r *RefreshTokenDB) UpsertBatch(ctx context.Context, tokens []*domain.RefreshToken) (int64, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.UpsertBatch")
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, revoked, family_id, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+              ON CONFLICT (id) DO NOTHING`
+
+	now := r.opts.now()
+	var inserted int64
+	for _, token := range tokens {
+		if token.FamilyID == uuid.Nil {
+			token.FamilyID = token.ID
+		}
+		if token.CreatedAt.IsZero() {
+			token.CreatedAt = now
+		}
+		if token.UpdatedAt.IsZero() {
+			token.UpdatedAt = now
+		}
+
+		result, err := tx.Exec(ctx, query, token.ID, token.UserID, token.RefreshToken, token.ExpiresAt, token.Revoked, token.FamilyID, token.CreatedAt, token.UpdatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert refresh token %s: %w", token.ID, err)
+		}
+		inserted += result.RowsAffected()
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return inserted, nil
+}
+
 func (r *RefreshTokenDB) Read(ctx context.Context, id uuid.UUID) (*domain.RefreshToken, error) {
-	query := `SELECT id, user_id, refresh_token, expires_at, created_at, updated_at
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.Read")
+	defer cancel()
+
+	query := `SELECT ` + refreshTokenColumns + `
               FROM refresh_tokens WHERE id = $1`
 	row := r.db.QueryRow(ctx, query, id)
 
 	var token domain.RefreshToken
-	err := row.Scan(&token.ID, &token.UserID, &token.RefreshToken, &token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
+	err := row.Scan(refreshTokenDest(&token)...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("refresh token not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrRefreshTokenNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to read refresh token: %w", err)
 	}
@@ -55,15 +165,18 @@ func (r *RefreshTokenDB) Read(ctx context.Context, id uuid.UUID) (*domain.Refres
 }
 
 func (r *RefreshTokenDB) ReadByRefreshToken(ctx context.Context, refreshToken string) (*domain.RefreshToken, error) {
-	query := `SELECT id, user_id, refresh_token, expires_at, created_at, updated_at
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ReadByRefreshToken")
+	defer cancel()
+
+	query := `SELECT ` + refreshTokenColumns + `
 	          FROM refresh_tokens WHERE refresh_token=$1`
 	row := r.db.QueryRow(ctx, query, refreshToken)
 
 	var token domain.RefreshToken
-	err := row.Scan(&token.ID, &token.UserID, &token.RefreshToken, &token.ExpiresAt, &token.CreatedAt, &token.UpdatedAt)
+	err := row.Scan(refreshTokenDest(&token)...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("refresh token not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrRefreshTokenNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to read refresh token: %w", err)
 	}
@@ -71,7 +184,609 @@ func (r *RefreshTokenDB) ReadByRefreshToken(ctx context.Context, refreshToken st
 	return &token, nil
 }
 
+func (r *RefreshTokenDB) ReadActiveByRefreshToken(ctx context.Context, refreshToken string) (*domain.RefreshToken, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ReadActiveByRefreshToken")
+	defer cancel()
+
+	query := `SELECT ` + refreshTokenColumns + `
+	          FROM refresh_tokens WHERE refresh_token=$1 AND expires_at > $2 AND NOT revoked`
+	row := r.db.QueryRow(ctx, query, refreshToken, r.opts.now())
+
+	var token domain.RefreshToken
+	err := row.Scan(refreshTokenDest(&token)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", ErrRefreshTokenNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to read refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// TokenStatus classifies the token at now so callers can branch on a single
+// value instead of inspecting errors. An unknown token is TokenNotFound with a
+// nil error; err is only set when the lookup itself fails.
+func (r *RefreshTokenDB) TokenStatus(ctx context.Context, refreshToken string, now time.Time) (domain.TokenStatus, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.TokenStatus")
+	defer cancel()
+
+	query := `SELECT expires_at, revoked FROM refresh_tokens WHERE refresh_token = $1`
+
+	var token domain.RefreshToken
+	err := r.db.QueryRow(ctx, query, refreshToken).Scan(&token.ExpiresAt, &token.Revoked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.TokenNotFound, nil
+		}
+		return domain.TokenNotFound, fmt.Errorf("failed to read refresh token status: %w", err)
+	}
+
+	return token.Status(now), nil
+}
+
+// ReadForRotation reads the token inside the caller's transaction and locks
+// the row FOR NO KEY UPDATE until it ends. Concurrent rotations of the same
+// token wait for each other, while foreign key checks, which only need a KEY
+// SHARE lock, are not blocked.
+func (r *RefreshTokenDB) ReadForRotation(ctx context.Context, tx pgx.Tx, refreshToken string) (*domain.RefreshToken, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ReadForRotation")
+	defer cancel()
+
+	query := `SELECT ` + refreshTokenColumns + `
+	          FROM refresh_tokens WHERE refresh_token = $1
+	          FOR NO KEY UPDATE`
+	row := tx.QueryRow(ctx, query, refreshToken)
+
+	var token domain.RefreshToken
+	err := row.Scan(refreshTokenDest(&token)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", ErrRefreshTokenNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to read refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// ClaimForRotation atomically marks an active token as rotated, revoking it
+// like MarkRotated, and returns it. Only one caller can claim a given token;
+// later callers get ErrRefreshRace.
+func (r *RefreshTokenDB) ClaimForRotation(ctx context.Context, refreshToken string, now time.Time) (*domain.RefreshToken, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ClaimForRotation")
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked = true, rotated_at = $3, updated_at = $3
+	          WHERE refresh_token = $1 AND rotated_at IS NULL AND NOT revoked AND expires_at > $2
+	          RETURNING ` + refreshTokenColumns
+	row := r.db.QueryRow(ctx, query, refreshToken, now, r.opts.now())
+
+	var token domain.RefreshToken
+	err := row.Scan(refreshTokenDest(&token)...)
+	if err == nil {
+		return &token, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to claim refresh token: %w", err)
+	}
+
+	// Nothing was updated: tell a lost race apart from an unknown, revoked
+	// or expired token
+	var rotated bool
+	query = `SELECT rotated_at IS NOT NULL FROM refresh_tokens WHERE refresh_token = $1`
+	err = r.db.QueryRow(ctx, query, refreshToken).Scan(&rotated)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to claim refresh token: %w", err)
+	}
+	if rotated {
+		return nil, ErrRefreshRace
+	}
+
+	return nil, ErrRefreshTokenNotFound
+}
+
+// RecordUse validates an active token and atomically increments its use
+// counter, returning the token with the new count. When a maximum is
+// configured with WithMaxTokenUses and the count exceeds it, ErrTokenOverused
+// is returned; the use is still recorded.
+func (r *RefreshTokenDB) RecordUse(ctx context.Context, refreshToken string, now time.Time) (*domain.RefreshToken, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.RecordUse")
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET use_count = use_count + 1
+	          WHERE refresh_token = $1 AND NOT revoked AND expires_at > $2
+	          RETURNING ` + refreshTokenColumns
+	row := r.db.QueryRow(ctx, query, refreshToken, now)
+
+	var token domain.RefreshToken
+	err := row.Scan(refreshTokenDest(&token)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", ErrRefreshTokenNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to record refresh token use: %w", err)
+	}
+
+	if r.opts.maxTokenUses > 0 && token.UseCount > r.opts.maxTokenUses {
+		return nil, ErrTokenOverused
+	}
+
+	return &token, nil
+}
+
+// BelongsTo reports whether the refresh token exists and is owned by the user
+// without loading the row.
+func (r *RefreshTokenDB) BelongsTo(ctx context.Context, refreshToken string, userID uuid.UUID) (bool, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.BelongsTo")
+	defer cancel()
+
+	query := `SELECT EXISTS(SELECT 1 FROM refresh_tokens WHERE refresh_token = $1 AND user_id = $2)`
+
+	var exists bool
+	err := r.db.QueryRow(ctx, query, refreshToken, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check refresh token owner: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetSession assembles the user and the refresh token into a single session,
+// rejecting tokens that are already revoked or expired with
+// ErrRefreshTokenRevoked or ErrRefreshTokenExpired.
+func (r *RefreshTokenDB) GetSession(ctx context.Context, refreshToken string) (*domain.Session, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.GetSession")
+	defer cancel()
+
+	query := `SELECT u.id, u.name, u.email, COALESCE(u.password_hash, ''), u.phone, u.created_at, u.updated_at,
+	                 t.id, t.user_id, t.refresh_token, t.expires_at, t.revoked, t.use_count, COALESCE(t.family_id, t.id), t.rotated_at, t.created_at, t.updated_at
+	          FROM refresh_tokens t JOIN users u ON u.id = t.user_id
+	          WHERE t.refresh_token=$1`
+	row := r.db.QueryRow(ctx, query, refreshToken)
+
+	var user domain.User
+	var token domain.RefreshToken
+	dest := append(userDest(&user), refreshTokenDest(&token)...)
+	err := row.Scan(dest...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", ErrRefreshTokenNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	if token.Revoked {
+		return nil, ErrRefreshTokenRevoked
+	}
+	if !token.ExpiresAt.After(r.opts.now()) {
+		return nil, ErrRefreshTokenExpired
+	}
+	r.opts.normalizeUsers(&user)
+
+	return &domain.Session{User: &user, RefreshToken: &token}, nil
+}
+
+// ListExpiringBetween returns the tokens whose expiry falls within [from, to),
+// ordered by expiry.
+func (r *RefreshTokenDB) ListExpiringBetween(ctx context.Context, from, to time.Time) ([]*domain.RefreshToken, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ListExpiringBetween")
+	defer cancel()
+
+	query := `SELECT ` + refreshTokenColumns + `
+	          FROM refresh_tokens WHERE expires_at >= $1 AND expires_at < $2 AND NOT revoked
+	          ORDER BY expires_at`
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring refresh tokens: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRefreshTokens(rows)
+}
+
+// FindOverlongTokens lists unexpired tokens whose lifetime exceeds
+// maxLifetime, e.g. tokens issued before the policy was tightened.
+func (r *RefreshTokenDB) FindOverlongTokens(ctx context.Context, maxLifetime time.Duration, now time.Time) ([]*domain.RefreshToken, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.FindOverlongTokens")
+	defer cancel()
+
+	query := `SELECT ` + refreshTokenColumns + `
+	          FROM refresh_tokens
+	          WHERE expires_at > created_at + $1 * interval '1 microsecond' AND expires_at > $2
+	          ORDER BY created_at, id`
+	rows, err := r.db.Query(ctx, query, maxLifetime.Microseconds(), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overlong refresh tokens: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRefreshTokens(rows)
+}
+
+// ClampOverlongTokens caps the expiry of every token found by
+// FindOverlongTokens to created_at + maxLifetime and returns how many were
+// changed.
+func (r *RefreshTokenDB) ClampOverlongTokens(ctx context.Context, maxLifetime time.Duration, now time.Time) (int64, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ClampOverlongTokens")
+	defer cancel()
+
+	query := `UPDATE refresh_tokens
+	          SET expires_at = created_at + $1 * interval '1 microsecond', updated_at = $3
+	          WHERE expires_at > created_at + $1 * interval '1 microsecond' AND expires_at > $2`
+	result, err := r.db.Exec(ctx, query, maxLifetime.Microseconds(), now, r.opts.now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to clamp overlong refresh tokens: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// ListActiveSessions returns a page of unexpired, unrevoked tokens across all
+// users, newest first.
+func (r *RefreshTokenDB) ListActiveSessions(ctx context.Context, now time.Time, limit, offset int) ([]*domain.RefreshToken, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ListActiveSessions")
+	defer cancel()
+
+	query := `SELECT ` + refreshTokenColumns + `
+	          FROM refresh_tokens WHERE expires_at > $1 AND NOT revoked
+	          ORDER BY created_at DESC, id
+	          LIMIT $2 OFFSET $3`
+	rows, err := r.db.Query(ctx, query, now, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRefreshTokens(rows)
+}
+
+// StreamExpired calls fn for every token that expired before now. Rows are
+// streamed from the server one at a time, so the whole set is never held in
+// memory. Iteration stops at the first error returned by fn.
+func (r *RefreshTokenDB) StreamExpired(ctx context.Context, now time.Time, fn func(*domain.RefreshToken) error) error {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.StreamExpired")
+	defer cancel()
+
+	query := `SELECT ` + refreshTokenColumns + `
+	          FROM refresh_tokens WHERE expires_at <= $1
+	          ORDER BY expires_at`
+	rows, err := r.db.Query(ctx, query, now)
+	if err != nil {
+		return fmt.Errorf("failed to stream expired refresh tokens: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token domain.RefreshToken
+		if err := rows.Scan(refreshTokenDest(&token)...); err != nil {
+			return fmt.Errorf("failed to scan refresh token: %w", err)
+		}
+		if err := fn(&token); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// ListTokensPage returns a page of the user's refresh tokens, newest first,
+// together with the total number of tokens the user holds. Both are read
+// from the same snapshot.
+func (r *RefreshTokenDB) ListTokensPage(ctx context.Context, userID uuid.UUID, limit, offset int) (*Page[*domain.RefreshToken], error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ListTokensPage")
+	defer cancel()
+
+	tx, release, err := readSnapshot(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var total int64
+	err = tx.QueryRow(ctx, `SELECT count(*) FROM refresh_tokens WHERE user_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count refresh tokens: %w", err)
+	}
+
+	query := `SELECT ` + refreshTokenColumns + `
+	          FROM refresh_tokens WHERE user_id = $1
+	          ORDER BY created_at DESC, id
+	          LIMIT $2 OFFSET $3`
+	rows, err := tx.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refresh tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens, err := scanRefreshTokens(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Page[*domain.RefreshToken]{Items: tokens, Total: total}, nil
+}
+
+// ListTokensWithUser returns tokens joined with their owner's email and name,
+// newest first. Tokens whose user no longer exists are left out.
+func (r *RefreshTokenDB) ListTokensWithUser(ctx context.Context, filter TokenFilter) ([]TokenWithUser, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ListTokensWithUser")
+	defer cancel()
+
+	var userID, activeAt any
+	if filter.UserID != uuid.Nil {
+		userID = filter.UserID
+	}
+	if !filter.ActiveAt.IsZero() {
+		activeAt = filter.ActiveAt
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `SELECT t.id, t.user_id, t.refresh_token, t.expires_at, t.revoked, t.use_count, COALESCE(t.family_id, t.id), t.rotated_at, t.created_at, t.updated_at,
+	                 u.email, u.name
+	          FROM refresh_tokens t JOIN users u ON u.id = t.user_id
+	          WHERE ($1::uuid IS NULL OR t.user_id = $1)
+	            AND ($2::timestamp IS NULL OR (t.expires_at > $2 AND NOT t.revoked))
+	          ORDER BY t.created_at DESC, t.id
+	          LIMIT $3 OFFSET $4`
+	rows, err := r.db.Query(ctx, query, userID, activeAt, limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refresh tokens with users: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []TokenWithUser
+	for rows.Next() {
+		var token TokenWithUser
+		var owner domain.User
+		dest := append(refreshTokenDest(&token.RefreshToken), &owner.Email, &owner.Name)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan refresh token: %w", err)
+		}
+		r.opts.normalizeUsers(&owner)
+		token.UserEmail, token.UserName = owner.Email, owner.Name
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate refresh tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// CountActiveSessions counts all tokens that are still valid at now.
+func (r *RefreshTokenDB) CountActiveSessions(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.CountActiveSessions")
+	defer cancel()
+
+	query := `SELECT count(*) FROM refresh_tokens WHERE expires_at > $1 AND NOT revoked`
+
+	var count int64
+	err := r.db.QueryRow(ctx, query, now).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active sessions: %w", err)
+	}
+
+	return count, nil
+}
+
+// ActiveSessionCounts returns the number of active tokens per user in a
+// single query. Users without active sessions are absent from the map.
+func (r *RefreshTokenDB) ActiveSessionCounts(ctx context.Context, userIDs []uuid.UUID, now time.Time) (map[uuid.UUID]int, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ActiveSessionCounts")
+	defer cancel()
+
+	counts := make(map[uuid.UUID]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `SELECT user_id, count(*) FROM refresh_tokens
+	          WHERE user_id = ANY($1) AND expires_at > $2 AND NOT revoked
+	          GROUP BY user_id`
+	rows, err := r.db.Query(ctx, query, userIDs, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active sessions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID uuid.UUID
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan session count: %w", err)
+		}
+		counts[userID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate session counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// FindDuplicateTokenValues reports token values stored more than once.
+func (r *RefreshTokenDB) FindDuplicateTokenValues(ctx context.Context) ([]string, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.FindDuplicateTokenValues")
+	defer cancel()
+
+	query := `SELECT refresh_token FROM refresh_tokens
+	          GROUP BY refresh_token HAVING count(*) > 1
+	          ORDER BY refresh_token`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate refresh tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to scan refresh token: %w", err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate refresh tokens: %w", err)
+	}
+
+	return values, nil
+}
+
+// SessionStats returns the number of active sessions of the user and the
+// creation times of the oldest and newest of them in one query.
+func (r *RefreshTokenDB) SessionStats(ctx context.Context, userID uuid.UUID, now time.Time) (SessionStats, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.SessionStats")
+	defer cancel()
+
+	query := `SELECT count(*), min(created_at), max(created_at) FROM refresh_tokens
+	          WHERE user_id = $1 AND expires_at > $2 AND NOT revoked`
+
+	var stats SessionStats
+	var earliest, latest *time.Time
+	err := r.db.QueryRow(ctx, query, userID, now).Scan(&stats.Active, &earliest, &latest)
+	if err != nil {
+		return SessionStats{}, fmt.Errorf("failed to read session stats: %w", err)
+	}
+	if earliest != nil {
+		stats.EarliestCreated = *earliest
+	}
+	if latest != nil {
+		stats.LatestCreated = *latest
+	}
+
+	return stats, nil
+}
+
+// ServerTime returns the database clock so clients can measure their own
+// skew against the time used for token expiry.
+func (r *RefreshTokenDB) ServerTime(ctx context.Context) (time.Time, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ServerTime")
+	defer cancel()
+
+	var now time.Time
+	err := r.db.QueryRow(ctx, `SELECT now()`).Scan(&now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read server time: %w", err)
+	}
+
+	return now, nil
+}
+
+// Expire marks the token as expired at the given time instead of deleting it,
+// so the row is kept for audit purposes. A zero time means now.
+func (r *RefreshTokenDB) Expire(ctx context.Context, id uuid.UUID, at time.Time) error {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.Expire")
+	defer cancel()
+
+	if at.IsZero() {
+		at = r.opts.now()
+	}
+
+	query := `UPDATE refresh_tokens SET expires_at = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.Exec(ctx, query, at, r.opts.now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to expire refresh token: %w", err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrRefreshTokenNotFound
+	}
+
+	return nil
+}
+
+// ExpireByUserIDs expires every token held by the given users in a single
+// statement and returns how many tokens were affected. A zero at means now.
+func (r *RefreshTokenDB) ExpireByUserIDs(ctx context.Context, userIDs []uuid.UUID, at time.Time) (int64, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.ExpireByUserIDs")
+	defer cancel()
+
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+	if at.IsZero() {
+		at = r.opts.now()
+	}
+
+	query := `UPDATE refresh_tokens SET expires_at = $1, updated_at = $2
+	          WHERE user_id = ANY($3) AND expires_at > $1`
+	result, err := r.db.Exec(ctx, query, at, r.opts.now(), userIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire refresh tokens: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// Revoke invalidates the token immediately while keeping the row for audit.
+func (r *RefreshTokenDB) Revoke(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.Revoke")
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked = true, updated_at = $1 WHERE id = $2`
+	result, err := r.db.Exec(ctx, query, r.opts.now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrRefreshTokenNotFound
+	}
+
+	return nil
+}
+
+// MarkRotated revokes the token and records that it was exchanged for a
+// successor at the given time, so presenting it again can be recognised as
+// reuse.
+func (r *RefreshTokenDB) MarkRotated(ctx context.Context, id uuid.UUID, at time.Time) error {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.MarkRotated")
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked = true, rotated_at = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.Exec(ctx, query, at, r.opts.now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark refresh token rotated: %w", err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrRefreshTokenNotFound
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every token of the family, e.g. after one of its
+// rotated tokens was presented again, and returns how many were revoked.
+func (r *RefreshTokenDB) RevokeFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.RevokeFamily")
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked = true, updated_at = $1
+	          WHERE COALESCE(family_id, id) = $2 AND NOT revoked`
+	result, err := r.db.Exec(ctx, query, r.opts.now(), familyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke token family: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
 func (r *RefreshTokenDB) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.Delete")
+	defer cancel()
+
 	query := `DELETE FROM refresh_tokens WHERE id = $1`
 	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
@@ -80,8 +795,100 @@ func (r *RefreshTokenDB) Delete(ctx context.Context, id uuid.UUID) error {
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("refresh token not found")
+		return ErrRefreshTokenNotFound
 	}
 
 	return nil
 }
+
+// RevokeSessionByID logs the user out of one session, identified by the
+// token's id. A session that does not exist or belongs to another user yields
+// ErrRefreshTokenNotFound, so ownership cannot be probed.
+func (r *RefreshTokenDB) RevokeSessionByID(ctx context.Context, userID, sessionID uuid.UUID) error {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.RevokeSessionByID")
+	defer cancel()
+
+	query := `DELETE FROM refresh_tokens WHERE id = $1 AND user_id = $2`
+	result, err := r.db.Exec(ctx, query, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrRefreshTokenNotFound
+	}
+
+	return nil
+}
+
+// DeleteByUserID removes every refresh token of the user, logging them out
+// everywhere, and returns how many were removed. A user without tokens is
+// not an error.
+func (r *RefreshTokenDB) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.DeleteByUserID")
+	defer cancel()
+
+	query := `DELETE FROM refresh_tokens WHERE user_id = $1`
+	result, err := r.db.Exec(ctx, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete refresh tokens: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// DeleteMany removes the tokens with the given ids in a single statement and
+// returns how many were removed. Unknown ids are skipped.
+func (r *RefreshTokenDB) DeleteMany(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.DeleteMany")
+	defer cancel()
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query := `DELETE FROM refresh_tokens WHERE id = ANY($1)`
+	result, err := r.db.Exec(ctx, query, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete refresh tokens: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// DeleteExpired removes every token whose expiry has passed and returns how
+// many were removed.
+func (r *RefreshTokenDB) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := r.opts.start(ctx, "RefreshTokenDB.DeleteExpired")
+	defer cancel()
+
+	query := `DELETE FROM refresh_tokens WHERE expires_at < $1`
+	result, err := r.db.Exec(ctx, query, r.opts.now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func scanRefreshTokens(rows pgx.Rows) ([]*domain.RefreshToken, error) {
+	var tokens []*domain.RefreshToken
+	for rows.Next() {
+		var token domain.RefreshToken
+		err := rows.Scan(refreshTokenDest(&token)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan refresh token: %w", err)
+		}
+		tokens = append(tokens, &token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate refresh tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func refreshTokenDest(token *domain.RefreshToken) []any {
+	return []any{&token.ID, &token.UserID, &token.RefreshToken, &token.ExpiresAt, &token.Revoked, &token.UseCount, &token.FamilyID, &token.RotatedAt, &token.CreatedAt, &token.UpdatedAt}
+}
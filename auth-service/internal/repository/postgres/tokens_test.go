@@ -3,11 +3,13 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -15,7 +17,7 @@ import (
 )
 
 // Helper function to setup PostgreSQL container
-func setupPostgresTokens(t *testing.T) (*pgx.Conn, func()) {
+func setupPostgresTokens(t *testing.T) (*pgxpool.Pool, func()) {
 	ctx := context.Background()
 
 	req := testcontainers.ContainerRequest{
@@ -41,23 +43,14 @@ func setupPostgresTokens(t *testing.T) (*pgx.Conn, func()) {
 	assert.NoError(t, err)
 
 	dsn := "postgres://user:password@" + host + ":" + port.Port() + "/testdb?sslmode=disable"
-	conn, err := pgx.Connect(context.Background(), dsn)
+	conn, err := pgxpool.New(context.Background(), dsn)
 	assert.NoError(t, err)
 
-	_, err = conn.Exec(ctx, `
-		CREATE TABLE refresh_tokens (
-			id UUID PRIMARY KEY,
-			user_id UUID NOT NULL,
-			refresh_token TEXT NOT NULL,
-			expires_at TIMESTAMP NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
+	_, err = conn.Exec(ctx, testSchema)
 	assert.NoError(t, err)
 
 	teardown := func() {
-		conn.Close(ctx)
+		conn.Close()
 		postgresContainer.Terminate(ctx)
 	}
 
@@ -156,3 +149,850 @@ func TestRefreshTokenDB_Delete(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, pgx.ErrNoRows))
 }
+
+func TestRefreshTokenDB_Expire(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	tokenID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		tokenID, uuid.New(), "example_refresh_token", time.Now().Add(24*time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	token, err := tokenDB.ReadActiveByRefreshToken(context.Background(), "example_refresh_token")
+	assert.NoError(t, err)
+	assert.NotNil(t, token)
+
+	err = tokenDB.Expire(context.Background(), tokenID, time.Time{})
+	assert.NoError(t, err)
+
+	// Verify the expired token is no longer active but still stored
+	token, err = tokenDB.ReadActiveByRefreshToken(context.Background(), "example_refresh_token")
+	assert.Error(t, err)
+	assert.Nil(t, token)
+
+	token, err = tokenDB.Read(context.Background(), tokenID)
+	assert.NoError(t, err)
+	assert.NotNil(t, token)
+	assert.False(t, token.ExpiresAt.After(time.Now()))
+}
+
+func TestRefreshTokenDB_GetSession(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		userID, "Alice", "alice@example.com", "hashedpassword", time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	_, err = conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), userID, "valid_refresh_token", time.Now().Add(24*time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	_, err = conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), userID, "expired_refresh_token", time.Now().Add(-time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	session, err := tokenDB.GetSession(context.Background(), "valid_refresh_token")
+	assert.NoError(t, err)
+	assert.NotNil(t, session)
+	assert.Equal(t, userID, session.User.ID)
+	assert.Equal(t, "alice@example.com", session.User.Email)
+	assert.Equal(t, "valid_refresh_token", session.RefreshToken.RefreshToken)
+
+	session, err = tokenDB.GetSession(context.Background(), "expired_refresh_token")
+	assert.ErrorIs(t, err, ErrRefreshTokenExpired)
+	assert.Nil(t, session)
+
+	_, err = conn.Exec(context.Background(), `UPDATE refresh_tokens SET revoked = true WHERE refresh_token = 'valid_refresh_token'`)
+	assert.NoError(t, err)
+
+	session, err = tokenDB.GetSession(context.Background(), "valid_refresh_token")
+	assert.ErrorIs(t, err, ErrRefreshTokenRevoked)
+	assert.Nil(t, session)
+}
+
+func TestRefreshTokenDB_ListExpiringBetween(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	expiries := map[string]time.Time{
+		"expires_in_30m": now.Add(30 * time.Minute),
+		"expires_in_2h":  now.Add(2 * time.Hour),
+		"expires_in_3d":  now.Add(72 * time.Hour),
+		"expired":        now.Add(-time.Hour),
+	}
+	for value, expiresAt := range expiries {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), uuid.New(), value, expiresAt, now, now)
+		assert.NoError(t, err)
+	}
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	tokens, err := tokenDB.ListExpiringBetween(context.Background(), now, now.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 2)
+	assert.Equal(t, "expires_in_30m", tokens[0].RefreshToken)
+	assert.Equal(t, "expires_in_2h", tokens[1].RefreshToken)
+}
+
+func TestRefreshTokenDB_BelongsTo(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), userID, "example_refresh_token", time.Now().Add(24*time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	belongs, err := tokenDB.BelongsTo(context.Background(), "example_refresh_token", userID)
+	assert.NoError(t, err)
+	assert.True(t, belongs)
+
+	belongs, err = tokenDB.BelongsTo(context.Background(), "example_refresh_token", uuid.New())
+	assert.NoError(t, err)
+	assert.False(t, belongs)
+}
+
+func TestRefreshTokenDB_ListTokensPage(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	userID := uuid.New()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), userID, fmt.Sprintf("refresh_token_%d", i), now.Add(24*time.Hour), now.Add(time.Duration(i)*time.Minute), now)
+		assert.NoError(t, err)
+	}
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), uuid.New(), "other_user_token", now.Add(24*time.Hour), now, now)
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	page, err := tokenDB.ListTokensPage(context.Background(), userID, 2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "refresh_token_2", page.Items[0].RefreshToken)
+	assert.Equal(t, "refresh_token_1", page.Items[1].RefreshToken)
+
+	page, err = tokenDB.ListTokensPage(context.Background(), userID, 2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, "refresh_token_0", page.Items[0].RefreshToken)
+}
+
+func TestRefreshTokenDB_CountActiveSessions(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	expiries := []time.Time{now.Add(time.Hour), now.Add(24 * time.Hour), now.Add(-time.Hour)}
+	for i, expiresAt := range expiries {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), uuid.New(), fmt.Sprintf("refresh_token_%d", i), expiresAt, now, now)
+		assert.NoError(t, err)
+	}
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	count, err := tokenDB.CountActiveSessions(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestRefreshTokenDB_Revoke(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	tokenID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		tokenID, uuid.New(), "example_refresh_token", time.Now().Add(24*time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	err = tokenDB.Revoke(context.Background(), tokenID)
+	assert.NoError(t, err)
+
+	// Verify the revoked token is no longer active but still stored
+	token, err := tokenDB.ReadActiveByRefreshToken(context.Background(), "example_refresh_token")
+	assert.Error(t, err)
+	assert.Nil(t, token)
+
+	token, err = tokenDB.Read(context.Background(), tokenID)
+	assert.NoError(t, err)
+	assert.True(t, token.Revoked)
+}
+
+func TestRefreshTokenDB_ExpireByUserIDs(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	userIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for i, userID := range userIDs {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), userID, fmt.Sprintf("refresh_token_%d", i), now.Add(24*time.Hour), now, now)
+		assert.NoError(t, err)
+	}
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	expired, err := tokenDB.ExpireByUserIDs(context.Background(), userIDs[:2], now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), expired)
+
+	count, err := tokenDB.CountActiveSessions(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Verify the third user's token is untouched
+	token, err := tokenDB.ReadByRefreshToken(context.Background(), "refresh_token_2")
+	assert.NoError(t, err)
+	assert.True(t, token.ExpiresAt.After(now))
+
+	// A zero time expires the tokens now
+	expired, err = tokenDB.ExpireByUserIDs(context.Background(), userIDs[2:], time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	token, err = tokenDB.ReadByRefreshToken(context.Background(), "refresh_token_2")
+	assert.NoError(t, err)
+	assert.False(t, token.ExpiresAt.After(time.Now()))
+}
+
+func TestRefreshTokenDB_StreamExpired(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	expiries := map[string]time.Time{
+		"expired_1": now.Add(-2 * time.Hour),
+		"expired_2": now.Add(-time.Minute),
+		"active":    now.Add(time.Hour),
+	}
+	for value, expiresAt := range expiries {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), uuid.New(), value, expiresAt, now, now)
+		assert.NoError(t, err)
+	}
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	var seen []string
+	err := tokenDB.StreamExpired(context.Background(), now, func(token *domain.RefreshToken) error {
+		seen = append(seen, token.RefreshToken)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"expired_1", "expired_2"}, seen)
+}
+
+func TestRefreshTokenDB_ActiveSessionCounts(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	tokens := []struct {
+		userID    uuid.UUID
+		expiresAt time.Time
+	}{
+		{alice, now.Add(time.Hour)},
+		{alice, now.Add(2 * time.Hour)},
+		{bob, now.Add(time.Hour)},
+		{bob, now.Add(-time.Hour)},
+		{carol, now.Add(-time.Hour)},
+	}
+	for i, tok := range tokens {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), tok.userID, fmt.Sprintf("refresh_token_%d", i), tok.expiresAt, now, now)
+		assert.NoError(t, err)
+	}
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	counts, err := tokenDB.ActiveSessionCounts(context.Background(), []uuid.UUID{alice, bob, carol}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int{alice: 2, bob: 1}, counts)
+}
+
+func TestRefreshTokenDB_ListTokensWithUser(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		userID, "Alice", "alice@example.com", "hashedpassword", now, now)
+	assert.NoError(t, err)
+
+	_, err = conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), userID, "alice_token", now.Add(time.Hour), now, now)
+	assert.NoError(t, err)
+
+	// Token of a user that has been deleted
+	_, err = conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), uuid.New(), "orphan_token", now.Add(time.Hour), now, now)
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	tokens, err := tokenDB.ListTokensWithUser(context.Background(), TokenFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 1)
+	assert.Equal(t, "alice_token", tokens[0].RefreshToken.RefreshToken)
+	assert.Equal(t, "alice@example.com", tokens[0].UserEmail)
+	assert.Equal(t, "Alice", tokens[0].UserName)
+
+	tokens, err = tokenDB.ListTokensWithUser(context.Background(), TokenFilter{UserID: uuid.New()})
+	assert.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	// Historical values are normalized like on every other user read
+	_, err = conn.Exec(context.Background(), `UPDATE users SET email = ' Alice@Example.com ', name = ' Alice ' WHERE id = $1`, userID)
+	assert.NoError(t, err)
+
+	tokens, err = NewRefreshTokenDB(conn, WithScanNormalization()).ListTokensWithUser(context.Background(), TokenFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, tokens, 1) {
+		assert.Equal(t, "alice@example.com", tokens[0].UserEmail)
+		assert.Equal(t, "Alice", tokens[0].UserName)
+	}
+}
+
+func TestRefreshTokenDB_FindDuplicateTokenValues(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	for _, value := range []string{"duplicated_token", "duplicated_token", "unique_token"} {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), uuid.New(), value, time.Now().Add(time.Hour), time.Now(), time.Now())
+		assert.NoError(t, err)
+	}
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	duplicates, err := tokenDB.FindDuplicateTokenValues(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"duplicated_token"}, duplicates)
+}
+
+func TestRefreshTokenDB_SessionStats(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	userID := uuid.New()
+	tokens := []struct {
+		createdAt time.Time
+		expiresAt time.Time
+	}{
+		{now.Add(-3 * time.Hour), now.Add(time.Hour)},
+		{now.Add(-2 * time.Hour), now.Add(time.Hour)},
+		{now.Add(-time.Hour), now.Add(time.Hour)},
+		{now.Add(-5 * time.Hour), now.Add(-time.Minute)},
+	}
+	for i, tok := range tokens {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), userID, fmt.Sprintf("refresh_token_%d", i), tok.expiresAt, tok.createdAt, tok.createdAt)
+		assert.NoError(t, err)
+	}
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	stats, err := tokenDB.SessionStats(context.Background(), userID, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Active)
+	assert.WithinDuration(t, now.Add(-3*time.Hour), stats.EarliestCreated, time.Second)
+	assert.WithinDuration(t, now.Add(-time.Hour), stats.LatestCreated, time.Second)
+
+	stats, err = tokenDB.SessionStats(context.Background(), uuid.New(), now)
+	assert.NoError(t, err)
+	assert.Zero(t, stats.Active)
+	assert.True(t, stats.EarliestCreated.IsZero())
+}
+
+func TestRefreshTokenDB_ServerTime(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	serverTime, err := tokenDB.ServerTime(context.Background())
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), serverTime, 5*time.Second)
+}
+
+func TestRefreshTokenDB_ClaimForRotation(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6), ($7, $2, $8, $9, $5, $6)`,
+		uuid.New(), uuid.New(), "active", now.Add(time.Hour), now, now,
+		uuid.New(), "expired", now.Add(-time.Hour))
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	const claimants = 8
+	results := make(chan error, claimants)
+	for i := 0; i < claimants; i++ {
+		go func() {
+			_, err := tokenDB.ClaimForRotation(context.Background(), "active", now)
+			results <- err
+		}()
+	}
+
+	var won, raced int
+	for i := 0; i < claimants; i++ {
+		err := <-results
+		switch {
+		case err == nil:
+			won++
+		case errors.Is(err, ErrRefreshRace):
+			raced++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 1, won)
+	assert.Equal(t, claimants-1, raced)
+
+	// A claimed token is no longer accepted as active
+	_, err = tokenDB.ReadActiveByRefreshToken(context.Background(), "active")
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+	status, err := tokenDB.TokenStatus(context.Background(), "active", now)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TokenRevoked, status)
+	_, err = tokenDB.RecordUse(context.Background(), "active", now)
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+
+	_, err = tokenDB.ClaimForRotation(context.Background(), "expired", now)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrRefreshRace)
+
+	_, err = tokenDB.ClaimForRotation(context.Background(), "missing", now)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrRefreshRace)
+}
+
+func TestRefreshTokenDB_UpsertBatch(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	userID := uuid.New()
+	tokens := []*domain.RefreshToken{
+		{ID: uuid.New(), UserID: userID, RefreshToken: "token-1", ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: uuid.New(), UserID: userID, RefreshToken: "token-2", ExpiresAt: time.Now().Add(time.Hour), Revoked: true},
+	}
+
+	inserted, err := tokenDB.UpsertBatch(context.Background(), tokens)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), inserted)
+
+	inserted, err = tokenDB.UpsertBatch(context.Background(), tokens)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), inserted)
+
+	stored, err := tokenDB.Read(context.Background(), tokens[1].ID)
+	assert.NoError(t, err)
+	assert.True(t, stored.Revoked)
+}
+
+func TestRefreshTokenDB_RecordUse(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), uuid.New(), "token", now.Add(time.Hour), now, now)
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn, WithMaxTokenUses(3))
+
+	for i := 1; i <= 3; i++ {
+		token, err := tokenDB.RecordUse(context.Background(), "token", now)
+		assert.NoError(t, err)
+		if assert.NotNil(t, token) {
+			assert.Equal(t, i, token.UseCount)
+		}
+	}
+
+	_, err = tokenDB.RecordUse(context.Background(), "token", now)
+	assert.ErrorIs(t, err, ErrTokenOverused)
+
+	var useCount int
+	err = conn.QueryRow(context.Background(), `SELECT use_count FROM refresh_tokens WHERE refresh_token = $1`, "token").Scan(&useCount)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, useCount)
+
+	_, err = tokenDB.RecordUse(context.Background(), "token", now.Add(2*time.Hour))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrTokenOverused)
+}
+
+func TestRefreshTokenDB_DeleteByUserID(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	userID, otherUserID := uuid.New(), uuid.New()
+	for i, owner := range []uuid.UUID{userID, userID, userID, otherUserID} {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), owner, fmt.Sprintf("token-%d", i), time.Now().Add(time.Hour), time.Now(), time.Now())
+		assert.NoError(t, err)
+	}
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	deleted, err := tokenDB.DeleteByUserID(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	var remaining int
+	err = conn.QueryRow(context.Background(), `SELECT count(*) FROM refresh_tokens WHERE user_id = $1`, otherUserID).Scan(&remaining)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, remaining)
+
+	deleted, err = tokenDB.DeleteByUserID(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+}
+
+func TestRefreshTokenDB_RevokeFamily(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	userID := uuid.New()
+	first := &domain.RefreshToken{UserID: userID, RefreshToken: "first", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, tokenDB.Create(context.Background(), first))
+	assert.Equal(t, first.ID, first.FamilyID)
+
+	second := &domain.RefreshToken{UserID: userID, RefreshToken: "second", ExpiresAt: time.Now().Add(time.Hour), FamilyID: first.FamilyID}
+	assert.NoError(t, tokenDB.Create(context.Background(), second))
+	assert.NoError(t, tokenDB.MarkRotated(context.Background(), first.ID, time.Now()))
+
+	// A token created before families existed is its own family
+	legacy := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at) VALUES ($1, $2, 'legacy', $3)`,
+		legacy, userID, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	stored, err := tokenDB.Read(context.Background(), legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, legacy, stored.FamilyID)
+	assert.Nil(t, stored.RotatedAt)
+
+	stored, err = tokenDB.Read(context.Background(), first.ID)
+	assert.NoError(t, err)
+	assert.True(t, stored.Revoked)
+	assert.NotNil(t, stored.RotatedAt)
+
+	// Only the still valid member of the family is newly revoked
+	revoked, err := tokenDB.RevokeFamily(context.Background(), first.FamilyID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), revoked)
+
+	stored, err = tokenDB.Read(context.Background(), second.ID)
+	assert.NoError(t, err)
+	assert.True(t, stored.Revoked)
+
+	stored, err = tokenDB.Read(context.Background(), legacy)
+	assert.NoError(t, err)
+	assert.False(t, stored.Revoked)
+
+	revoked, err = tokenDB.RevokeFamily(context.Background(), legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), revoked)
+}
+
+func TestRefreshTokenDB_RevokeSessionByID(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	userID, otherUserID := uuid.New(), uuid.New()
+	own, foreign := uuid.New(), uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at) VALUES
+		($1, $3, 'own', $5),
+		($2, $4, 'foreign', $5)`,
+		own, foreign, userID, otherUserID, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	err = tokenDB.RevokeSessionByID(context.Background(), userID, own)
+	assert.NoError(t, err)
+	_, err = tokenDB.Read(context.Background(), own)
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+
+	// Another user's session is indistinguishable from a missing one
+	err = tokenDB.RevokeSessionByID(context.Background(), userID, foreign)
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+	_, err = tokenDB.Read(context.Background(), foreign)
+	assert.NoError(t, err)
+
+	err = tokenDB.RevokeSessionByID(context.Background(), userID, own)
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+}
+
+func TestRefreshTokenDB_DeleteMany(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	for i, id := range ids {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			id, uuid.New(), fmt.Sprintf("token-%d", i), time.Now().Add(time.Hour), time.Now(), time.Now())
+		assert.NoError(t, err)
+	}
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	deleted, err := tokenDB.DeleteMany(context.Background(), []uuid.UUID{ids[0], ids[2], ids[3], uuid.New()})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	_, err = tokenDB.Read(context.Background(), ids[1])
+	assert.NoError(t, err)
+
+	deleted, err = tokenDB.DeleteMany(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+}
+
+func TestRefreshTokenDB_TokenStatus(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, revoked, created_at, updated_at) VALUES
+		($1, $4, 'valid', $5, false, $7, $7),
+		($2, $4, 'expired', $6, false, $7, $7),
+		($3, $4, 'revoked', $5, true, $7, $7)`,
+		uuid.New(), uuid.New(), uuid.New(), uuid.New(), now.Add(time.Hour), now.Add(-time.Hour), now)
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	tests := []struct {
+		token string
+		want  domain.TokenStatus
+	}{
+		{"valid", domain.TokenValid},
+		{"expired", domain.TokenExpired},
+		{"revoked", domain.TokenRevoked},
+		{"missing", domain.TokenNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			status, err := tokenDB.TokenStatus(context.Background(), tt.token, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, status)
+		})
+	}
+}
+
+func TestRefreshTokenDB_ReadForRotation(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	ctx := context.Background()
+	tokenID := uuid.New()
+	_, err := conn.Exec(ctx, `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		tokenID, uuid.New(), "token", time.Now().Add(time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	// beginWithLockTimeout makes a blocked lock fail fast instead of hanging
+	beginWithLockTimeout := func() pgx.Tx {
+		tx, err := conn.Begin(ctx)
+		assert.NoError(t, err)
+		_, err = tx.Exec(ctx, `SET LOCAL lock_timeout = '200ms'`)
+		assert.NoError(t, err)
+		return tx
+	}
+
+	first, err := conn.Begin(ctx)
+	assert.NoError(t, err)
+	token, err := tokenDB.ReadForRotation(ctx, first, "token")
+	assert.NoError(t, err)
+	assert.Equal(t, tokenID, token.ID)
+
+	// A second rotation waits for the first
+	second := beginWithLockTimeout()
+	_, err = tokenDB.ReadForRotation(ctx, second, "token")
+	assert.Error(t, err)
+	assert.NoError(t, second.Rollback(ctx))
+
+	// A foreign key check on the same row is not blocked
+	keyShare := beginWithLockTimeout()
+	_, err = keyShare.Exec(ctx, `SELECT 1 FROM refresh_tokens WHERE id = $1 FOR KEY SHARE`, tokenID)
+	assert.NoError(t, err)
+	assert.NoError(t, keyShare.Rollback(ctx))
+
+	assert.NoError(t, first.Commit(ctx))
+
+	second = beginWithLockTimeout()
+	_, err = tokenDB.ReadForRotation(ctx, second, "token")
+	assert.NoError(t, err)
+	assert.NoError(t, second.Rollback(ctx))
+}
+
+func TestRefreshTokenDB_NotFound(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	_, err := tokenDB.Read(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	_, err = tokenDB.ReadByRefreshToken(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+
+	err = tokenDB.Revoke(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+
+	err = tokenDB.Delete(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+}
+
+func TestRefreshTokenDB_DeleteExpired(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	expiredID, validID := uuid.New(), uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES
+		($1, $3, 'expired', $4, $6, $6),
+		($2, $3, 'valid', $5, $6, $6)`,
+		expiredID, validID, uuid.New(), time.Now().Add(-time.Hour), time.Now().Add(time.Hour), time.Now())
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	deleted, err := tokenDB.DeleteExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = tokenDB.Read(context.Background(), expiredID)
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+
+	_, err = tokenDB.Read(context.Background(), validID)
+	assert.NoError(t, err)
+}
+
+func TestRefreshTokenDB_StartCleanupWorker(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), uuid.New(), "expired", time.Now().Add(-time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := NewRefreshTokenDB(conn).StartCleanupWorker(ctx, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		var count int
+		err := conn.QueryRow(context.Background(), `SELECT count(*) FROM refresh_tokens`).Scan(&count)
+		return err == nil && count == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cleanup worker did not stop")
+	}
+}
+
+func TestRefreshTokenDB_ClampOverlongTokens(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	createdAt := now.Add(-24 * time.Hour)
+	overlongID, compliantID := uuid.New(), uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES
+		($1, $3, 'overlong', $4, $6, $6),
+		($2, $3, 'compliant', $5, $6, $6)`,
+		overlongID, compliantID, uuid.New(), createdAt.Add(90*24*time.Hour), createdAt.Add(7*24*time.Hour), createdAt)
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+	maxLifetime := 30 * 24 * time.Hour
+
+	overlong, err := tokenDB.FindOverlongTokens(context.Background(), maxLifetime, now)
+	assert.NoError(t, err)
+	if assert.Len(t, overlong, 1) {
+		assert.Equal(t, overlongID, overlong[0].ID)
+	}
+
+	clamped, err := tokenDB.ClampOverlongTokens(context.Background(), maxLifetime, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), clamped)
+
+	token, err := tokenDB.Read(context.Background(), overlongID)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, createdAt.Add(maxLifetime), token.ExpiresAt, time.Second)
+
+	overlong, err = tokenDB.FindOverlongTokens(context.Background(), maxLifetime, now)
+	assert.NoError(t, err)
+	assert.Empty(t, overlong)
+}
+
+func TestRefreshTokenDB_ListActiveSessions(t *testing.T) {
+	conn, teardown := setupPostgresTokens(t)
+	defer teardown()
+
+	now := time.Now()
+	var active []uuid.UUID
+	for i := 0; i < 5; i++ {
+		id := uuid.New()
+		createdAt := now.Add(-time.Duration(i) * time.Minute)
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			id, uuid.New(), fmt.Sprintf("active-%d", i), now.Add(time.Hour), createdAt, createdAt)
+		assert.NoError(t, err)
+		active = append(active, id)
+	}
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, revoked, created_at, updated_at) VALUES
+		($1, $3, 'expired', $4, false, $6, $6),
+		($2, $3, 'revoked', $5, true, $6, $6)`,
+		uuid.New(), uuid.New(), uuid.New(), now.Add(-time.Hour), now.Add(time.Hour), now)
+	assert.NoError(t, err)
+
+	tokenDB := NewRefreshTokenDB(conn)
+
+	var listed []uuid.UUID
+	for offset := 0; ; offset += 2 {
+		page, err := tokenDB.ListActiveSessions(context.Background(), now, 2, offset)
+		assert.NoError(t, err)
+		for _, token := range page {
+			listed = append(listed, token.ID)
+		}
+		if len(page) < 2 {
+			break
+		}
+	}
+	assert.Equal(t, active, listed)
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"todoservice/auth-service/internal/domain"
+)
+
+// TxManager runs several repository calls in a single transaction.
+type TxManager struct {
+	db      DBTX
+	opts    []Option
+	options options
+}
+
+// NewTxManager returns a TxManager beginning transactions on db. The options
+// are applied to the transactions themselves, so their statements are logged
+// and measured too, and to the transaction-scoped repositories.
+func NewTxManager(db DBTX, opts ...Option) *TxManager {
+	o := newOptions(opts)
+
+	return &TxManager{
+		db:      o.wrap(db),
+		opts:    opts,
+		options: o,
+	}
+}
+
+// WithTx begins a transaction and passes repositories bound to it to fn. The
+// transaction commits when fn returns nil and is rolled back otherwise; fn's
+// error is returned unchanged. With WithSynchronousCommit the commit waits
+// for the WAL to be flushed.
+func (m *TxManager) WithTx(ctx context.Context, fn func(users *UserDB, tokens *RefreshTokenDB) error) error {
+	ctx = withOp(ctx, "TxManager.WithTx")
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if m.options.syncCommit {
+		if _, err = tx.Exec(ctx, `SET LOCAL synchronous_commit = on`); err != nil {
+			return fmt.Errorf("failed to enable synchronous commit: %w", err)
+		}
+	}
+
+	if err = fn(NewUserDB(tx, m.opts...), NewRefreshTokenDB(tx, m.opts...)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SnapshotExport reads every user and every refresh token from the same
+// REPEATABLE READ snapshot, so writes committed while the export runs cannot
+// leave tokens without their user or the other way round.
+func (m *TxManager) SnapshotExport(ctx context.Context) (users []*domain.User, tokens []*domain.RefreshToken, err error) {
+	ctx, cancel := m.options.start(ctx, "TxManager.SnapshotExport")
+	defer cancel()
+
+	tx, release, err := readSnapshot(ctx, m.db)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	query := `SELECT ` + userColumns + `
+	          FROM users ORDER BY created_at, id`
+	userRows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to export users: %w", err)
+	}
+	users, err = scanUsers(userRows)
+	userRows.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	m.options.normalizeUsers(users...)
+
+	query = `SELECT ` + refreshTokenColumns + `
+	         FROM refresh_tokens ORDER BY created_at, id`
+	tokenRows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to export refresh tokens: %w", err)
+	}
+	defer tokenRows.Close()
+
+	tokens, err = scanRefreshTokens(tokenRows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return users, tokens, nil
+}
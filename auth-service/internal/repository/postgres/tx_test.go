@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"todoservice/auth-service/internal/domain"
+)
+
+func TestTxManager_WithTx(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	txManager := NewTxManager(conn)
+
+	// Signup: the user and their first token are committed together
+	user := &domain.User{Name: "Alice", Email: "alice@example.com", PasswordHash: "hashedpassword"}
+	err := txManager.WithTx(context.Background(), func(users *UserDB, tokens *RefreshTokenDB) error {
+		if err := users.Create(context.Background(), user); err != nil {
+			return err
+		}
+		return tokens.Create(context.Background(), &domain.RefreshToken{
+			UserID:       user.ID,
+			RefreshToken: "first_refresh_token",
+			ExpiresAt:    time.Now().Add(time.Hour),
+		})
+	})
+	assert.NoError(t, err)
+
+	_, err = NewUserDB(conn).Read(context.Background(), user.ID)
+	assert.NoError(t, err)
+	_, err = NewRefreshTokenDB(conn).ReadByRefreshToken(context.Background(), "first_refresh_token")
+	assert.NoError(t, err)
+
+	// An error from the callback rolls back the insert
+	errAbort := errors.New("abort")
+	bob := &domain.User{Name: "Bob", Email: "bob@example.com", PasswordHash: "hashedpassword"}
+	err = txManager.WithTx(context.Background(), func(users *UserDB, tokens *RefreshTokenDB) error {
+		if err := users.Create(context.Background(), bob); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	_, err = NewUserDB(conn).Read(context.Background(), bob.ID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestTxManager_SnapshotReadsInTx(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	metrics := &recordingMetrics{}
+	txManager := NewTxManager(conn, WithMetrics(metrics))
+
+	user := &domain.User{Name: "Alice", Email: "alice@example.com", PasswordHash: "hashedpassword"}
+	err := txManager.WithTx(context.Background(), func(users *UserDB, tokens *RefreshTokenDB) error {
+		if err := users.Create(context.Background(), user); err != nil {
+			return err
+		}
+		err := tokens.Create(context.Background(), &domain.RefreshToken{
+			UserID:       user.ID,
+			RefreshToken: "first_refresh_token",
+			ExpiresAt:    time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			return err
+		}
+
+		// Snapshot reads reuse the surrounding transaction
+		page, err := tokens.ListTokensPage(context.Background(), user.ID, 10, 0)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, int64(1), page.Total)
+
+		export, err := users.ExportUserData(context.Background(), user.ID)
+		if err != nil {
+			return err
+		}
+		assert.Len(t, export.Sessions, 1)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	assert.Contains(t, metrics.ops, "RefreshTokenDB.ListTokensPage")
+	assert.Contains(t, metrics.ops, "UserDB.ExportUserData")
+	assert.Zero(t, metrics.errors)
+}
+
+func TestTxManager_WithSynchronousCommit(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	// Relax durability for every new connection, then drop the existing ones
+	_, err := conn.Exec(context.Background(), `ALTER DATABASE testdb SET synchronous_commit = off`)
+	assert.NoError(t, err)
+	conn.Reset()
+
+	setting := func(txManager *TxManager) string {
+		var value string
+		err := txManager.WithTx(context.Background(), func(users *UserDB, tokens *RefreshTokenDB) error {
+			return users.db.QueryRow(context.Background(), `SHOW synchronous_commit`).Scan(&value)
+		})
+		assert.NoError(t, err)
+		return value
+	}
+
+	assert.Equal(t, "off", setting(NewTxManager(conn)))
+	assert.Equal(t, "on", setting(NewTxManager(conn, WithSynchronousCommit())))
+
+	// SET LOCAL does not leak past the transaction
+	var value string
+	err = conn.QueryRow(context.Background(), `SHOW synchronous_commit`).Scan(&value)
+	assert.NoError(t, err)
+	assert.Equal(t, "off", value)
+}
+
+func TestTxManager_SnapshotExport(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	txManager := NewTxManager(conn)
+
+	// Each user is committed together with their token while exports run
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			err := txManager.WithTx(context.Background(), func(users *UserDB, tokens *RefreshTokenDB) error {
+				user := &domain.User{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), PasswordHash: "hashedpassword"}
+				if err := users.Create(context.Background(), user); err != nil {
+					return err
+				}
+				return tokens.Create(context.Background(), &domain.RefreshToken{
+					UserID:       user.ID,
+					RefreshToken: fmt.Sprintf("token-%d", i),
+					ExpiresAt:    time.Now().Add(time.Hour),
+				})
+			})
+			assert.NoError(t, err)
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		users, tokens, err := txManager.SnapshotExport(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, tokens, len(users))
+
+		exported := make(map[string]bool, len(users))
+		for _, user := range users {
+			exported[user.ID.String()] = true
+		}
+		for _, token := range tokens {
+			assert.True(t, exported[token.UserID.String()], "token %s exported without its user", token.ID)
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+
+	users, tokens, err := txManager.SnapshotExport(context.Background())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, users)
+	assert.Len(t, tokens, len(users))
+}
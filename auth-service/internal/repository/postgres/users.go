@@ -1,93 +1,1051 @@
 package postgres
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"sort"
+	"strings"
 	"time"
 	"todoservice/auth-service/internal/domain"
 )
 
+// userColumns reads the NULL password hash of a passwordless user as "".
+const userColumns = `id, name, email, COALESCE(password_hash, ''), phone, created_at, updated_at`
+
+// publicUserColumns is userColumns without the password hash, for listings
+// that feed API responses.
+const publicUserColumns = `id, name, email, phone, created_at, updated_at`
+
+// maxListRecent caps how many users ListRecent returns.
+const maxListRecent = 100
+
+// defaultListLimit and maxListLimit bound the page size of List.
+const (
+	defaultListLimit = 50
+	maxListLimit     = 100
+)
+
+// bulkPredicateColumns and bulkSetColumns whitelist the columns BulkUpdate
+// may filter on and assign.
+var (
+	bulkPredicateColumns = map[string]bool{
+		"name": true, "email": true, "phone": true, "import_batch_id": true,
+		"password_changed_at": true, "created_at": true, "updated_at": true,
+	}
+	bulkSetColumns = map[string]bool{
+		"name": true, "phone": true, "import_batch_id": true, "password_changed_at": true,
+	}
+	bulkOperators = map[string]bool{"=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true}
+)
+
+// Condition is a single column comparison used by BulkUpdate, e.g.
+// {Column: "created_at", Op: "<", Value: cutoff}.
+type Condition struct {
+	Column string
+	Op     string
+	Value  any
+}
+
+// seatLimitLockKey is the advisory lock serializing seat-limited inserts.
+const seatLimitLockKey = 7390001
+
 type UserDB struct {
-	db *pgx.Conn
+	db   DBTX
+	opts options
 }
 
-func NewUserDB(db *pgx.Conn) *UserDB {
+func NewUserDB(db DBTX, opts ...Option) *UserDB {
+	o := newOptions(opts)
+
 	return &UserDB{
-		db: db,
+		db:   o.wrap(db),
+		opts: o,
 	}
 }
 
+// Create inserts a new user, storing the email normalized with
+// domain.NormalizeEmail. Non-zero CreatedAt/UpdatedAt values provided by
+// the caller are preserved so historical data can be imported as is. When a
+// seat limit is configured, the insert is rejected with ErrSeatLimitReached
+// once the limit is met.
 func (u *UserDB) Create(ctx context.Context, user *domain.User) error {
+	ctx, cancel := u.opts.start(ctx, "UserDB.Create")
+	defer cancel()
+
+	now := u.opts.now()
 	user.ID = uuid.New()
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
+	user.Email = domain.NormalizeEmail(user.Email)
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
 
-	query := `INSERT INTO users (id, name, email, password_hash, created_at, updated_at)
-              VALUES ($1, $2, $3, $4, $5, $6)`
+	query := `INSERT INTO users (id, name, email, password_hash, phone, created_at, updated_at, email_normalized, email_domain, hash_algorithm)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	if u.opts.seatLimit > 0 {
+		return u.createWithinSeatLimit(ctx, query, user)
+	}
 
-	_, err := u.db.Exec(ctx, query, user.ID, user.Name, user.Email, user.PasswordHash, user.CreatedAt, user.UpdatedAt)
+	_, err := u.db.Exec(ctx, query, user.ID, user.Name, user.Email, user.PasswordHash, user.Phone, user.CreatedAt, user.UpdatedAt, u.opts.emailKey(user.Email), domain.EmailDomain(user.Email), u.opts.hashAlgo())
 	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrEmailAlreadyExists
+		}
 		return fmt.Errorf("failed to insert user: %w", err)
 	}
 
 	return nil
 }
 
+// createWithinSeatLimit counts and inserts under a transaction-scoped
+// advisory lock so concurrent creates cannot overshoot the limit.
+func (u *UserDB) createWithinSeatLimit(ctx context.Context, query string, user *domain.User) error {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	free, err := u.freeSeats(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if free < 1 {
+		return ErrSeatLimitReached
+	}
+
+	_, err = tx.Exec(ctx, query, user.ID, user.Name, user.Email, user.PasswordHash, user.Phone, user.CreatedAt, user.UpdatedAt, u.opts.emailKey(user.Email), domain.EmailDomain(user.Email), u.opts.hashAlgo())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// freeSeats takes the seat limit lock for the rest of tx and returns how many
+// more users fit under the limit.
+func (u *UserDB) freeSeats(ctx context.Context, tx pgx.Tx) (int64, error) {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, seatLimitLockKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire seat lock: %w", err)
+	}
+
+	var count int64
+	err = tx.QueryRow(ctx, `SELECT count(*) FROM users`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return int64(u.opts.seatLimit) - count, nil
+}
+
+// CreateBatch inserts the users in a single transaction, stamping each row
+// with the import batch marker so a faulty import can be rolled back later.
+// When a seat limit is configured, a batch that does not fit is rejected as a
+// whole with ErrSeatLimitReached.
+func (u *UserDB) CreateBatch(ctx context.Context, batchID uuid.UUID, users []*domain.User) error {
+	ctx, cancel := u.opts.start(ctx, "UserDB.CreateBatch")
+	defer cancel()
+
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if u.opts.seatLimit > 0 {
+		free, err := u.freeSeats(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if free < int64(len(users)) {
+			return ErrSeatLimitReached
+		}
+	}
+
+	query := `INSERT INTO users (id, name, email, password_hash, phone, import_batch_id, created_at, updated_at, email_normalized, email_domain, hash_algorithm)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	now := u.opts.now()
+	for _, user := range users {
+		user.ID = uuid.New()
+		user.Email = domain.NormalizeEmail(user.Email)
+		if user.CreatedAt.IsZero() {
+			user.CreatedAt = now
+		}
+		if user.UpdatedAt.IsZero() {
+			user.UpdatedAt = now
+		}
+
+		_, err = tx.Exec(ctx, query, user.ID, user.Name, user.Email, user.PasswordHash, user.Phone, batchID, user.CreatedAt, user.UpdatedAt, u.opts.emailKey(user.Email), domain.EmailDomain(user.Email), u.opts.hashAlgo())
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrEmailAlreadyExists
+			}
+			return fmt.Errorf("failed to insert user: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateBatchPartial inserts each user in its own savepoint so that a failing
+// row (e.g. a duplicate email) does not abort the rest of the batch. It
+// returns the ids of the inserted users and the per-index failures; err is
+// only set when the batch as a whole could not be processed. When a seat
+// limit is configured, the users past the free seats fail with
+// ErrSeatLimitReached.
+func (u *UserDB) CreateBatchPartial(ctx context.Context, users []*domain.User) ([]uuid.UUID, map[int]error, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.CreateBatchPartial")
+	defer cancel()
+
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	free := int64(len(users))
+	if u.opts.seatLimit > 0 {
+		free, err = u.freeSeats(ctx, tx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	query := `INSERT INTO users (id, name, email, password_hash, phone, created_at, updated_at, email_normalized, email_domain, hash_algorithm)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	var inserted []uuid.UUID
+	failures := make(map[int]error)
+	now := u.opts.now()
+	for i, user := range users {
+		if int64(len(inserted)) >= free {
+			failures[i] = ErrSeatLimitReached
+			user.ID = uuid.Nil
+			continue
+		}
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		user.ID = uuid.New()
+		user.Email = domain.NormalizeEmail(user.Email)
+		if user.CreatedAt.IsZero() {
+			user.CreatedAt = now
+		}
+		if user.UpdatedAt.IsZero() {
+			user.UpdatedAt = now
+		}
+
+		_, err = savepoint.Exec(ctx, query, user.ID, user.Name, user.Email, user.PasswordHash, user.Phone, user.CreatedAt, user.UpdatedAt, u.opts.emailKey(user.Email), domain.EmailDomain(user.Email), u.opts.hashAlgo())
+		if err != nil {
+			failures[i] = fmt.Errorf("failed to insert user: %w", err)
+			if isUniqueViolation(err) {
+				failures[i] = ErrEmailAlreadyExists
+			}
+			user.ID = uuid.Nil
+			if err := savepoint.Rollback(ctx); err != nil {
+				return nil, nil, fmt.Errorf("failed to roll back savepoint: %w", err)
+			}
+			continue
+		}
+
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		inserted = append(inserted, user.ID)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return inserted, failures, nil
+}
+
 func (u *UserDB) Read(ctx context.Context, id uuid.UUID) (*domain.User, error) {
-	query := `SELECT id, name, email, password_hash, created_at, updated_at
+	ctx, cancel := u.opts.start(ctx, "UserDB.Read")
+	defer cancel()
+
+	query := `SELECT ` + userColumns + `
               FROM users WHERE id = $1`
 	row := u.db.QueryRow(ctx, query, id)
 
 	var user domain.User
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(userDest(&user)...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("user not found")
+			return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to read user: %w", err)
 	}
+	u.opts.normalizeUsers(&user)
 
 	return &user, nil
 }
 
+// ReadByEmail looks the user up by email, normalized the same way as on
+// write, so "Alice@Example.com " finds "alice@example.com".
 func (u *UserDB) ReadByEmail(ctx context.Context, email string) (*domain.User, error) {
-	query := `SELECT id, name, email, password_hash, created_at, updated_at
+	ctx, cancel := u.opts.start(ctx, "UserDB.ReadByEmail")
+	defer cancel()
+
+	query := `SELECT ` + userColumns + `
 	          FROM users WHERE email=$1`
-	row := u.db.QueryRow(ctx, query, email)
+	row := u.db.QueryRow(ctx, query, domain.NormalizeEmail(email))
+
+	var user domain.User
+	err := row.Scan(userDest(&user)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to read user: %w", err)
+	}
+	u.opts.normalizeUsers(&user)
+
+	return &user, nil
+}
+
+// ReadByNormalizedEmail looks the user up by the homoglyph-resistant lookup
+// key (see domain.EmailLookupKey), so "\u0430lice@example.com" written with a
+// Cyrillic "а" finds "alice@example.com" when confusable folding is enabled.
+func (u *UserDB) ReadByNormalizedEmail(ctx context.Context, email string) (*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ReadByNormalizedEmail")
+	defer cancel()
+
+	query := `SELECT ` + userColumns + `
+	          FROM users WHERE email_normalized = $1`
+	row := u.db.QueryRow(ctx, query, u.opts.emailKey(email))
 
 	var user domain.User
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(userDest(&user)...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("user not found: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
 		}
 		return nil, fmt.Errorf("failed to read user: %w", err)
 	}
+	u.opts.normalizeUsers(&user)
 
 	return &user, nil
 }
 
+// ReadByEmailOrID reads the user by id when identifier parses as a UUID and
+// by email otherwise, for admin search boxes accepting either.
+func (u *UserDB) ReadByEmailOrID(ctx context.Context, identifier string) (*domain.User, error) {
+	identifier = strings.TrimSpace(identifier)
+	if id, err := uuid.Parse(identifier); err == nil {
+		return u.Read(ctx, id)
+	}
+
+	return u.ReadByEmail(ctx, identifier)
+}
+
+func (u *UserDB) ListByImportBatch(ctx context.Context, batchID uuid.UUID) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ListByImportBatch")
+	defer cancel()
+
+	query := `SELECT ` + userColumns + `
+	          FROM users WHERE import_batch_id = $1 ORDER BY created_at, id`
+	rows, err := u.db.Query(ctx, query, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users by import batch: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
+// ListByRecentActivity returns users ranked by their most recently created
+// refresh token. Users without any session come last.
+func (u *UserDB) ListByRecentActivity(ctx context.Context, limit int) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ListByRecentActivity")
+	defer cancel()
+
+	query := `SELECT u.id, u.name, u.email, COALESCE(u.password_hash, ''), u.phone, u.created_at, u.updated_at
+	          FROM users u
+	          LEFT JOIN (SELECT user_id, max(created_at) AS last_activity
+	                     FROM refresh_tokens GROUP BY user_id) t ON t.user_id = u.id
+	          ORDER BY t.last_activity DESC NULLS LAST, u.id
+	          LIMIT $1`
+	rows, err := u.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users by activity: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
+// CountCreatedSince counts the users created at or after since.
+func (u *UserDB) CountCreatedSince(ctx context.Context, since time.Time) (int64, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.CountCreatedSince")
+	defer cancel()
+
+	query := `SELECT count(*) FROM users WHERE created_at >= $1`
+
+	var count int64
+	err := u.db.QueryRow(ctx, query, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountByEmailDomain counts users whose email belongs to the given domain,
+// compared case-insensitively.
+func (u *UserDB) CountByEmailDomain(ctx context.Context, emailDomain string) (int64, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.CountByEmailDomain")
+	defer cancel()
+
+	query := `SELECT count(*) FROM users WHERE email_domain = $1`
+
+	var count int64
+	err := u.db.QueryRow(ctx, query, domain.NormalizeEmail(emailDomain)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users by email domain: %w", err)
+	}
+
+	return count, nil
+}
+
+func (u *UserDB) ListByEmailDomain(ctx context.Context, emailDomain string, limit, offset int) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ListByEmailDomain")
+	defer cancel()
+
+	query := `SELECT ` + userColumns + `
+	          FROM users WHERE email_domain = $1
+	          ORDER BY email, id
+	          LIMIT $2 OFFSET $3`
+	rows, err := u.db.Query(ctx, query, domain.NormalizeEmail(emailDomain), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users by email domain: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
+// SearchByEmail returns up to limit users whose email starts with prefix,
+// compared case-insensitively and ordered by email, for autocompletion. An
+// empty prefix matches nobody rather than everybody.
+func (u *UserDB) SearchByEmail(ctx context.Context, prefix string, limit int) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.SearchByEmail")
+	defer cancel()
+
+	if prefix == "" {
+		return nil, nil
+	}
+
+	query := `SELECT ` + publicUserColumns + `
+	          FROM users WHERE email ILIKE $1 || '%' ESCAPE '\'
+	          ORDER BY email, id
+	          LIMIT $2`
+	rows, err := u.db.Query(ctx, query, escapeLike(prefix), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users by email: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanPublicUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
+// ListOlderThan returns up to limit users whose account is older than age at
+// now, oldest first.
+func (u *UserDB) ListOlderThan(ctx context.Context, age time.Duration, now time.Time, limit int) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ListOlderThan")
+	defer cancel()
+
+	query := `SELECT ` + userColumns + `
+	          FROM users WHERE created_at < $1
+	          ORDER BY created_at, id
+	          LIMIT $2`
+	rows, err := u.db.Query(ctx, query, now.Add(-age), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users older than %s: %w", age, err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
+// ListPasswordless returns users without a usable password, i.e. whose
+// password hash is NULL or empty, such as accounts created through a social
+// login.
+func (u *UserDB) ListPasswordless(ctx context.Context, limit, offset int) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ListPasswordless")
+	defer cancel()
+
+	query := `SELECT ` + userColumns + `
+	          FROM users WHERE password_hash IS NULL OR password_hash = ''
+	          ORDER BY created_at, id
+	          LIMIT $1 OFFSET $2`
+	rows, err := u.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passwordless users: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
+// ListDormantUsers returns users without an unexpired, unrevoked refresh
+// token at now, including users who never had one, oldest account first.
+func (u *UserDB) ListDormantUsers(ctx context.Context, now time.Time, limit, offset int) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ListDormantUsers")
+	defer cancel()
+
+	query := `SELECT ` + publicUserColumns + `
+	          FROM users u
+	          WHERE NOT EXISTS (
+	              SELECT 1 FROM refresh_tokens t WHERE t.user_id = u.id AND t.expires_at > $1 AND NOT t.revoked
+	          )
+	          ORDER BY created_at, id
+	          LIMIT $2 OFFSET $3`
+	rows, err := u.db.Query(ctx, query, now, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dormant users: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanPublicUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
+// ListRecent returns the n most recently created users, newest first. The
+// password hash is not selected. n is clamped to [1, maxListRecent].
+func (u *UserDB) ListRecent(ctx context.Context, n int) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ListRecent")
+	defer cancel()
+
+	if n <= 0 {
+		n = 1
+	}
+	if n > maxListRecent {
+		n = maxListRecent
+	}
+
+	query := `SELECT ` + publicUserColumns + `
+	          FROM users ORDER BY created_at DESC, id LIMIT $1`
+	rows, err := u.db.Query(ctx, query, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent users: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanPublicUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
+// List returns a page of users ordered by creation time and id, so pages are
+// stable. The password hash is not selected. A non-positive limit defaults to
+// defaultListLimit and larger limits are capped at maxListLimit.
+func (u *UserDB) List(ctx context.Context, limit, offset int) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.List")
+	defer cancel()
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `SELECT ` + publicUserColumns + `
+	          FROM users ORDER BY created_at, id
+	          LIMIT $1 OFFSET $2`
+	rows, err := u.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanPublicUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
 func (u *UserDB) Update(ctx context.Context, user *domain.User) error {
-	user.UpdatedAt = time.Now()
+	ctx, cancel := u.opts.start(ctx, "UserDB.Update")
+	defer cancel()
+
+	user.UpdatedAt = u.opts.now()
+	user.Email = domain.NormalizeEmail(user.Email)
 
-	query := `UPDATE users SET name = $1, email = $2, password_hash = $3, updated_at = $4 WHERE id = $5`
-	result, err := u.db.Exec(ctx, query, user.Name, user.Email, user.PasswordHash, user.UpdatedAt, user.ID)
+	query := `UPDATE users SET name = $1, email = $2, password_hash = $3, updated_at = $4, email_normalized = $6, email_domain = $7,
+	              password_changed_at = CASE WHEN password_hash IS DISTINCT FROM $3 THEN $4 ELSE password_changed_at END,
+	              hash_algorithm = CASE WHEN password_hash IS DISTINCT FROM $3 THEN $8 ELSE hash_algorithm END
+	          WHERE id = $5`
+	result, err := u.db.Exec(ctx, query, user.Name, user.Email, user.PasswordHash, user.UpdatedAt, user.ID, u.opts.emailKey(user.Email), domain.EmailDomain(user.Email), u.opts.hashAlgo())
 	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrEmailAlreadyExists
+		}
 		return fmt.Errorf("failed to update user: %w", err)
 	}
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateReturning updates the user and returns the row as stored, so callers
+// get the authoritative updated_at without a second round trip.
+func (u *UserDB) UpdateReturning(ctx context.Context, user *domain.User) (*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.UpdateReturning")
+	defer cancel()
+
+	query := `UPDATE users SET name = $1, email = $2, password_hash = $3, updated_at = $4, email_normalized = $6, email_domain = $7,
+	              password_changed_at = CASE WHEN password_hash IS DISTINCT FROM $3 THEN $4 ELSE password_changed_at END,
+	              hash_algorithm = CASE WHEN password_hash IS DISTINCT FROM $3 THEN $8 ELSE hash_algorithm END
+	          WHERE id = $5
+	          RETURNING ` + userColumns
+	row := u.db.QueryRow(ctx, query, user.Name, domain.NormalizeEmail(user.Email), user.PasswordHash, u.opts.now(), user.ID, u.opts.emailKey(user.Email), domain.EmailDomain(user.Email), u.opts.hashAlgo())
+
+	var updated domain.User
+	err := row.Scan(userDest(&updated)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+		}
+		if isUniqueViolation(err) {
+			return nil, ErrEmailAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	u.opts.normalizeUsers(&updated)
+
+	return &updated, nil
+}
+
+// WithUserLock locks the user row FOR UPDATE in a new transaction and passes
+// it to fn, so concurrent operations on the same user are serialized. The
+// transaction commits when fn returns nil and is rolled back otherwise; fn's
+// error is returned unchanged.
+func (u *UserDB) WithUserLock(ctx context.Context, id uuid.UUID, fn func(tx pgx.Tx, user *domain.User) error) error {
+	ctx, cancel := u.opts.start(ctx, "UserDB.WithUserLock")
+	defer cancel()
+
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT ` + userColumns + `
+	          FROM users WHERE id = $1 FOR UPDATE`
+
+	var user domain.User
+	err = tx.QueryRow(ctx, query, id).Scan(userDest(&user)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+		}
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	u.opts.normalizeUsers(&user)
+
+	if err = fn(tx, &user); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// BulkUpdate assigns set to every user matching all conditions of the
+// predicate and returns how many users were updated. Rows are updated in
+// batches of batchSize in separate statements, walking the ids in order, so
+// no single statement holds row locks on the whole cohort. Only whitelisted
+// columns and comparison operators are accepted.
+func (u *UserDB) BulkUpdate(ctx context.Context, predicate []Condition, set map[string]any, batchSize int) (int64, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.BulkUpdate")
+	defer cancel()
+
+	if len(set) == 0 {
+		return 0, fmt.Errorf("bulk update needs at least one column to set")
+	}
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("invalid batch size %d", batchSize)
+	}
+
+	// $1 is the id cursor and $2 the batch size
+	args := []any{uuid.Nil, batchSize}
+
+	where := []string{"id > $1"}
+	for _, cond := range predicate {
+		if !bulkPredicateColumns[cond.Column] || !bulkOperators[cond.Op] {
+			return 0, fmt.Errorf("unsupported bulk update condition %s %s", cond.Column, cond.Op)
+		}
+		args = append(args, cond.Value)
+		where = append(where, fmt.Sprintf("%s %s $%d", cond.Column, cond.Op, len(args)))
+	}
+
+	columns := make([]string, 0, len(set))
+	for column := range set {
+		if !bulkSetColumns[column] {
+			return 0, fmt.Errorf("unsupported bulk update column %s", column)
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	assignments := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		args = append(args, set[column])
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	args = append(args, u.opts.now())
+	assignments = append(assignments, fmt.Sprintf("updated_at = $%d", len(args)))
+
+	query := `WITH batch AS (
+	              SELECT id FROM users WHERE ` + strings.Join(where, " AND ") + `
+	              ORDER BY id LIMIT $2
+	          )
+	          UPDATE users SET ` + strings.Join(assignments, ", ") + `
+	          FROM batch WHERE users.id = batch.id
+	          RETURNING users.id`
+
+	var total int64
+	for {
+		rows, err := u.db.Query(ctx, query, args...)
+		if err != nil {
+			return total, fmt.Errorf("failed to bulk update users: %w", err)
+		}
+
+		var updated int
+		last := args[0].(uuid.UUID)
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return total, fmt.Errorf("failed to scan user id: %w", err)
+			}
+			if bytes.Compare(id[:], last[:]) > 0 {
+				last = id
+			}
+			updated++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, fmt.Errorf("failed to bulk update users: %w", err)
+		}
+
+		total += int64(updated)
+		if updated < batchSize {
+			return total, nil
+		}
+		args[0] = last
+	}
+}
+
+// BackfillEmailDomains fills email_domain for rows written before the column
+// existed, batchSize rows per statement, and returns how many were filled.
+// The domain is derived the same way as domain.EmailDomain.
+func (u *UserDB) BackfillEmailDomains(ctx context.Context, batchSize int) (int64, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.BackfillEmailDomains")
+	defer cancel()
+
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("invalid batch size %d", batchSize)
+	}
+
+	query := `WITH batch AS (
+	              SELECT id FROM users WHERE email_domain IS NULL AND email IS NOT NULL
+	              ORDER BY id LIMIT $1
+	          )
+	          UPDATE users SET email_domain = COALESCE(substring(lower(btrim(email)) from '@([^@]*)$'), '')
+	          FROM batch WHERE users.id = batch.id`
+
+	var total int64
+	for {
+		result, err := u.db.Exec(ctx, query, batchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to backfill email domains: %w", err)
+		}
+
+		total += result.RowsAffected()
+		if result.RowsAffected() < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// MustResetPassword reports whether the user has to set a new password, either
+// because forced is set (e.g. after a breach) or because the password is older
+// than maxAge. A password never changed since sign-up is as old as the account.
+// A non-positive maxAge disables the age policy.
+func (u *UserDB) MustResetPassword(ctx context.Context, userID uuid.UUID, maxAge time.Duration, forced bool) (bool, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.MustResetPassword")
+	defer cancel()
+
+	query := `SELECT COALESCE(password_changed_at, created_at) FROM users WHERE id = $1`
+
+	var changedAt time.Time
+	err := u.db.QueryRow(ctx, query, userID).Scan(&changedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+		}
+		return false, fmt.Errorf("failed to read password age: %w", err)
+	}
+
+	if forced {
+		return true, nil
+	}
+	if maxAge <= 0 {
+		return false, nil
+	}
+
+	return u.opts.now().Sub(changedAt) > maxAge, nil
+}
+
+// ExportUserData reads the user and all of their refresh tokens from a single
+// snapshot and assembles the data-portability bundle.
+func (u *UserDB) ExportUserData(ctx context.Context, userID uuid.UUID) (*domain.UserExport, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ExportUserData")
+	defer cancel()
+
+	tx, release, err := readSnapshot(ctx, u.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	query := `SELECT ` + userColumns + `
+              FROM users WHERE id = $1`
+	var user domain.User
+	err = tx.QueryRow(ctx, query, userID).Scan(userDest(&user)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+		}
+		return nil, fmt.Errorf("failed to read user: %w", err)
+	}
+	u.opts.normalizeUsers(&user)
+
+	query = `SELECT ` + refreshTokenColumns + `
+	         FROM refresh_tokens WHERE user_id = $1 ORDER BY created_at`
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refresh tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens, err := scanRefreshTokens(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewUserExport(&user, tokens, u.opts.now()), nil
+}
+
+// UpdatePhone sets the user's phone number. The number must already be in
+// E.164 form; an empty string clears it.
+func (u *UserDB) UpdatePhone(ctx context.Context, id uuid.UUID, phone string) error {
+	ctx, cancel := u.opts.start(ctx, "UserDB.UpdatePhone")
+	defer cancel()
+
+	var value *string
+	if phone != "" {
+		if err := domain.ValidatePhone(phone); err != nil {
+			return err
+		}
+		value = &phone
+	}
+
+	query := `UPDATE users SET phone = $1, updated_at = $2 WHERE id = $3`
+	result, err := u.db.Exec(ctx, query, value, u.opts.now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update phone: %w", err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdatePassword replaces only the password hash, stamping updated_at,
+// password_changed_at and the configured hash_algorithm.
+func (u *UserDB) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	ctx, cancel := u.opts.start(ctx, "UserDB.UpdatePassword")
+	defer cancel()
+
+	query := `UPDATE users SET password_hash = $1, updated_at = $2, password_changed_at = $2, hash_algorithm = $4 WHERE id = $3`
+	result, err := u.db.Exec(ctx, query, passwordHash, u.opts.now(), id, u.opts.hashAlgo())
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// RehashPassword replaces the hash of an unchanged password with one produced
+// by algorithm, e.g. after a login verified it against an outdated hash. The
+// password age (password_changed_at) is left alone.
+func (u *UserDB) RehashPassword(ctx context.Context, id uuid.UUID, passwordHash, algorithm string) error {
+	ctx, cancel := u.opts.start(ctx, "UserDB.RehashPassword")
+	defer cancel()
+
+	query := `UPDATE users SET password_hash = $1, hash_algorithm = $2, updated_at = $3 WHERE id = $4`
+	result, err := u.db.Exec(ctx, query, passwordHash, algorithm, u.opts.now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to rehash password: %w", err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// ListHashesNeedingUpgrade returns up to limit users with a password whose
+// hash_algorithm differs from currentAlgo. Hashes without a recorded
+// algorithm predate the marker and are listed as well.
+func (u *UserDB) ListHashesNeedingUpgrade(ctx context.Context, currentAlgo string, limit int) ([]*domain.User, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.ListHashesNeedingUpgrade")
+	defer cancel()
+
+	query := `SELECT ` + publicUserColumns + `
+	          FROM users
+	          WHERE hash_algorithm IS DISTINCT FROM $1 AND password_hash IS NOT NULL AND password_hash <> ''
+	          ORDER BY created_at, id
+	          LIMIT $2`
+	rows, err := u.db.Query(ctx, query, currentAlgo, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users needing a rehash: %w", err)
+	}
+	defer rows.Close()
+
+	users, err := scanPublicUsers(rows)
+	if err != nil {
+		return nil, err
+	}
+	u.opts.normalizeUsers(users...)
+
+	return users, nil
+}
+
+// UpdateEmail replaces only the email. An email already used by another
+// user yields ErrEmailAlreadyExists.
+func (u *UserDB) UpdateEmail(ctx context.Context, id uuid.UUID, email string) error {
+	ctx, cancel := u.opts.start(ctx, "UserDB.UpdateEmail")
+	defer cancel()
+
+	query := `UPDATE users SET email = $1, updated_at = $2, email_normalized = $4, email_domain = $5 WHERE id = $3`
+	result, err := u.db.Exec(ctx, query, domain.NormalizeEmail(email), u.opts.now(), id, u.opts.emailKey(email), domain.EmailDomain(email))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+
+	rowsAffected := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrUserNotFound
 	}
 
 	return nil
 }
 
 func (u *UserDB) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := u.opts.start(ctx, "UserDB.Delete")
+	defer cancel()
+
 	query := `DELETE FROM users WHERE id = $1`
 	result, err := u.db.Exec(ctx, query, id)
 	if err != nil {
@@ -96,8 +1054,150 @@ func (u *UserDB) Delete(ctx context.Context, id uuid.UUID) error {
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
 	}
 
 	return nil
 }
+
+// DeleteIfNoActiveSessions deletes the user unless they hold a refresh token
+// that is neither revoked nor expired at now, in which case
+// ErrUserHasActiveSessions is returned. The user's remaining expired or
+// revoked tokens are deleted with it. The user row is locked before the check,
+// which waits out a RefreshTokenDB.Create in flight and blocks new ones until
+// the delete commits.
+func (u *UserDB) DeleteIfNoActiveSessions(ctx context.Context, id uuid.UUID, now time.Time) error {
+	ctx, cancel := u.opts.start(ctx, "UserDB.DeleteIfNoActiveSessions")
+	defer cancel()
+
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked uuid.UUID
+	err = tx.QueryRow(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, id).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+		}
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	// Checked in a separate statement so its snapshot includes tokens
+	// committed while waiting for the lock
+	query := `SELECT EXISTS (
+	              SELECT 1 FROM refresh_tokens
+	              WHERE user_id = $1 AND expires_at > $2 AND NOT revoked
+	          )`
+
+	var active bool
+	err = tx.QueryRow(ctx, query, id, now).Scan(&active)
+	if err != nil {
+		return fmt.Errorf("failed to check active sessions: %w", err)
+	}
+	if active {
+		return ErrUserHasActiveSessions
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM refresh_tokens WHERE user_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete refresh tokens: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByImportBatch removes every user created by the given import batch,
+// together with their refresh tokens, and returns how many users were
+// deleted. An unknown batch is not an error.
+func (u *UserDB) DeleteByImportBatch(ctx context.Context, batchID uuid.UUID) (int64, error) {
+	ctx, cancel := u.opts.start(ctx, "UserDB.DeleteByImportBatch")
+	defer cancel()
+
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `DELETE FROM refresh_tokens
+	          WHERE user_id IN (SELECT id FROM users WHERE import_batch_id = $1)`
+	_, err = tx.Exec(ctx, query, batchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete refresh tokens by import batch: %w", err)
+	}
+
+	query = `DELETE FROM users WHERE import_batch_id = $1`
+	result, err := tx.Exec(ctx, query, batchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete users by import batch: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func scanUsers(rows pgx.Rows) ([]*domain.User, error) {
+	var users []*domain.User
+	for rows.Next() {
+		var user domain.User
+		err := rows.Scan(userDest(&user)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, &user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return users, nil
+}
+
+// scanPublicUsers scans rows selected with publicUserColumns.
+func scanPublicUsers(rows pgx.Rows) ([]*domain.User, error) {
+	var users []*domain.User
+	for rows.Next() {
+		var user domain.User
+		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Phone, &user.CreatedAt, &user.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, &user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate users: %w", err)
+	}
+
+	return users, nil
+}
+
+func userDest(user *domain.User) []any {
+	return []any{&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.Phone, &user.CreatedAt, &user.UpdatedAt}
+}
+
+// isUniqueViolation reports whether err is a Postgres unique_violation
+// (SQLSTATE 23505).
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// escapeLike escapes the LIKE wildcards so s is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -2,12 +2,16 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -15,7 +19,7 @@ import (
 )
 
 // Helper function to setup PostgreSQL container
-func setupPostgres(t *testing.T) (*pgx.Conn, func()) {
+func setupPostgres(t *testing.T) (*pgxpool.Pool, func()) {
 	ctx := context.Background()
 
 	req := testcontainers.ContainerRequest{
@@ -41,23 +45,14 @@ func setupPostgres(t *testing.T) (*pgx.Conn, func()) {
 	assert.NoError(t, err)
 
 	dsn := "postgres://user:password@" + host + ":" + port.Port() + "/testdb?sslmode=disable"
-	conn, err := pgx.Connect(context.Background(), dsn)
+	conn, err := pgxpool.New(context.Background(), dsn)
 	assert.NoError(t, err)
 
-	_, err = conn.Exec(ctx, `
-		CREATE TABLE users (
-			id UUID PRIMARY KEY,
-			name VARCHAR(100),
-			email VARCHAR(100) UNIQUE,
-			password_hash VARCHAR(100),
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
+	_, err = conn.Exec(ctx, testSchema)
 	assert.NoError(t, err)
 
 	teardown := func() {
-		conn.Close(ctx)
+		conn.Close()
 		postgresContainer.Terminate(ctx)
 	}
 
@@ -95,6 +90,55 @@ func TestUserDB_Create(t *testing.T) {
 	assert.Equal(t, user.PasswordHash, insertedUser.PasswordHash)
 }
 
+func TestUserDB_CreatePreservesTimestamps(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn)
+
+	createdAt := time.Date(2019, time.March, 14, 9, 30, 0, 0, time.UTC)
+	user := &domain.User{
+		Name:         "Alice",
+		Email:        "alice@example.com",
+		PasswordHash: "hashedpassword",
+		CreatedAt:    createdAt,
+	}
+
+	err := userDB.Create(context.Background(), user)
+	assert.NoError(t, err)
+
+	// Verify the back-dated created_at was preserved
+	var insertedCreatedAt time.Time
+	err = conn.QueryRow(context.Background(), `SELECT created_at FROM users WHERE id = $1`, user.ID).Scan(&insertedCreatedAt)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, createdAt, insertedCreatedAt, time.Second)
+	assert.True(t, user.UpdatedAt.After(createdAt))
+}
+
+func TestUserDB_CreateDuplicateEmail(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn)
+
+	err := userDB.Create(context.Background(), &domain.User{Name: "Alice", Email: "alice@example.com", PasswordHash: "hashedpassword"})
+	assert.NoError(t, err)
+
+	err = userDB.Create(context.Background(), &domain.User{Name: "Alice Again", Email: "alice@example.com", PasswordHash: "hashedpassword"})
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+
+	bob := &domain.User{Name: "Bob", Email: "bob@example.com", PasswordHash: "hashedpassword"}
+	err = userDB.Create(context.Background(), bob)
+	assert.NoError(t, err)
+
+	bob.Email = "alice@example.com"
+	err = userDB.Update(context.Background(), bob)
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+
+	_, err = userDB.UpdateReturning(context.Background(), bob)
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+}
+
 func TestUserDB_Read(t *testing.T) {
 	conn, teardown := setupPostgres(t)
 	defer teardown()
@@ -133,6 +177,80 @@ func TestUserDB_ReadByEmail(t *testing.T) {
 	assert.Equal(t, "hashedpassword", user.PasswordHash)
 }
 
+func TestUserDB_EmailNormalization(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn)
+
+	user := &domain.User{Name: "Alice", Email: " Alice@Example.com ", PasswordHash: "hashedpassword"}
+	err := userDB.Create(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", user.Email)
+
+	read, err := userDB.ReadByEmail(context.Background(), "ALICE@example.COM")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, read.ID)
+	assert.Equal(t, "alice@example.com", read.Email)
+
+	err = userDB.Create(context.Background(), &domain.User{Name: "Alice Again", Email: "alice@EXAMPLE.com", PasswordHash: "hashedpassword"})
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+
+	user.Email = "  Alice.Smith@Example.com"
+	err = userDB.Update(context.Background(), user)
+	assert.NoError(t, err)
+
+	read, err = userDB.ReadByEmail(context.Background(), "alice.smith@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, read.ID)
+}
+
+func TestUserDB_ReadByNormalizedEmail(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn, WithConfusableFolding())
+
+	user := &domain.User{Name: "Alice", Email: "alice@example.com", PasswordHash: "hashedpassword"}
+	err := userDB.Create(context.Background(), user)
+	assert.NoError(t, err)
+
+	// Cyrillic "а" and "е" look identical to the Latin letters
+	homoglyph := "\u0430lic\u0435@example.com"
+
+	read, err := userDB.ReadByNormalizedEmail(context.Background(), homoglyph)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, read.ID)
+
+	err = userDB.Create(context.Background(), &domain.User{Name: "Mallory", Email: homoglyph, PasswordHash: "hashedpassword"})
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+
+	// Without folding the homoglyph is a different account
+	err = NewUserDB(conn).Create(context.Background(), &domain.User{Name: "Mallory", Email: homoglyph, PasswordHash: "hashedpassword"})
+	assert.NoError(t, err)
+}
+
+func TestUserDB_NotFound(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn)
+	missing := &domain.User{ID: uuid.New(), Name: "Nobody", Email: "nobody@example.com", PasswordHash: "hashedpassword"}
+
+	_, err := userDB.Read(context.Background(), missing.ID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	_, err = userDB.ReadByEmail(context.Background(), missing.Email)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	err = userDB.Update(context.Background(), missing)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	err = userDB.Delete(context.Background(), missing.ID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
 func TestUserDB_Update(t *testing.T) {
 	conn, teardown := setupPostgres(t)
 	defer teardown()
@@ -171,6 +289,333 @@ func TestUserDB_Update(t *testing.T) {
 	assert.Equal(t, updatedUser.PasswordHash, user.PasswordHash)
 }
 
+func TestUserDB_UpdateReturning(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userID := uuid.New()
+	originalUpdatedAt := time.Now().Add(-time.Hour)
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		userID, "Alice", "alice@example.com", "hashedpassword", originalUpdatedAt, originalUpdatedAt)
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	updated, err := userDB.UpdateReturning(context.Background(), &domain.User{
+		ID:           userID,
+		Name:         "Alice Updated",
+		Email:        "alice@example.com",
+		PasswordHash: "hashedpassword",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, userID, updated.ID)
+	assert.Equal(t, "Alice Updated", updated.Name)
+	assert.True(t, updated.UpdatedAt.After(originalUpdatedAt))
+	assert.WithinDuration(t, originalUpdatedAt, updated.CreatedAt, time.Second)
+}
+
+func TestUserDB_MustResetPassword(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	now := time.Now()
+	oldPassword, freshPassword := uuid.New(), uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, password_changed_at, created_at, updated_at) VALUES
+		($1, 'Alice', 'alice@example.com', 'hashedpassword', $3, $3, $3),
+		($2, 'Bob', 'bob@example.com', 'hashedpassword', NULL, $4, $4)`,
+		oldPassword, freshPassword, now.Add(-200*24*time.Hour), now.Add(-time.Hour))
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+	maxAge := 90 * 24 * time.Hour
+
+	mustReset, err := userDB.MustResetPassword(context.Background(), oldPassword, maxAge, false)
+	assert.NoError(t, err)
+	assert.True(t, mustReset)
+
+	mustReset, err = userDB.MustResetPassword(context.Background(), freshPassword, maxAge, false)
+	assert.NoError(t, err)
+	assert.False(t, mustReset)
+
+	mustReset, err = userDB.MustResetPassword(context.Background(), freshPassword, maxAge, true)
+	assert.NoError(t, err)
+	assert.True(t, mustReset)
+
+	// Changing the password resets its age
+	err = userDB.Update(context.Background(), &domain.User{ID: oldPassword, Name: "Alice", Email: "alice@example.com", PasswordHash: "newhashedpassword"})
+	assert.NoError(t, err)
+
+	mustReset, err = userDB.MustResetPassword(context.Background(), oldPassword, maxAge, false)
+	assert.NoError(t, err)
+	assert.False(t, mustReset)
+
+	_, err = userDB.MustResetPassword(context.Background(), uuid.New(), maxAge, false)
+	assert.Error(t, err)
+}
+
+func TestUserDB_WithUserLock(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		userID, "", "alice@example.com", "hashedpassword", time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	// Each worker does a read-modify-write of the name; without the row lock
+	// concurrent appends would get lost
+	const workers, rounds = 2, 5
+	errs := make(chan error, workers*rounds)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := 0; i < rounds; i++ {
+				errs <- userDB.WithUserLock(context.Background(), userID, func(tx pgx.Tx, user *domain.User) error {
+					time.Sleep(10 * time.Millisecond)
+					_, err := tx.Exec(context.Background(), `UPDATE users SET name = $1 WHERE id = $2`, user.Name+"x", user.ID)
+					return err
+				})
+			}
+		}()
+	}
+	for i := 0; i < workers*rounds; i++ {
+		assert.NoError(t, <-errs)
+	}
+
+	user, err := userDB.Read(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", workers*rounds), user.Name)
+
+	// An error from the callback rolls back and is returned as is
+	errAbort := errors.New("abort")
+	err = userDB.WithUserLock(context.Background(), userID, func(tx pgx.Tx, user *domain.User) error {
+		_, err := tx.Exec(context.Background(), `UPDATE users SET name = 'rolled back' WHERE id = $1`, user.ID)
+		assert.NoError(t, err)
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	user, err = userDB.Read(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", workers*rounds), user.Name)
+
+	err = userDB.WithUserLock(context.Background(), uuid.New(), func(pgx.Tx, *domain.User) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestUserDB_BulkUpdate(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	for i := 0; i < 7; i++ {
+		createdAt := cutoff.Add(-time.Hour)
+		if i >= 5 {
+			createdAt = cutoff.Add(time.Hour)
+		}
+		_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), "User", fmt.Sprintf("user%d@example.com", i), "hashedpassword", createdAt, createdAt)
+		assert.NoError(t, err)
+	}
+
+	userDB := NewUserDB(conn)
+
+	// Force a password reset for the cohort created before the cutoff
+	expired := time.Unix(0, 0).UTC()
+	updated, err := userDB.BulkUpdate(context.Background(),
+		[]Condition{{Column: "created_at", Op: "<", Value: cutoff}},
+		map[string]any{"password_changed_at": expired},
+		2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), updated)
+
+	var flagged int
+	err = conn.QueryRow(context.Background(), `SELECT count(*) FROM users WHERE password_changed_at = $1`, expired).Scan(&flagged)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, flagged)
+
+	_, err = userDB.BulkUpdate(context.Background(), nil, map[string]any{"password_hash": "x"}, 10)
+	assert.Error(t, err)
+
+	_, err = userDB.BulkUpdate(context.Background(),
+		[]Condition{{Column: "id; DROP TABLE users", Op: "=", Value: 1}},
+		map[string]any{"name": "x"}, 10)
+	assert.Error(t, err)
+}
+
+func TestUserDB_UpdatePasswordAndEmail(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES
+		($1, 'Alice', 'alice@example.com', 'hashedpassword', $3, $3),
+		($2, 'Bob', 'bob@example.com', 'hashedpassword', $3, $3)`,
+		userID, uuid.New(), time.Now())
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	err = userDB.UpdatePassword(context.Background(), userID, "newhashedpassword")
+	assert.NoError(t, err)
+
+	err = userDB.UpdateEmail(context.Background(), userID, "alice@example.org")
+	assert.NoError(t, err)
+
+	user, err := userDB.Read(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", user.Name)
+	assert.Equal(t, "alice@example.org", user.Email)
+	assert.Equal(t, "newhashedpassword", user.PasswordHash)
+
+	err = userDB.UpdateEmail(context.Background(), userID, "bob@example.com")
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+
+	err = userDB.UpdatePassword(context.Background(), uuid.New(), "newhashedpassword")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	err = userDB.UpdateEmail(context.Background(), uuid.New(), "carol@example.com")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestUserDB_ListHashesNeedingUpgrade(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	current, outdated, legacy, passwordless := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, hash_algorithm, created_at, updated_at) VALUES
+		($1, 'Current', 'current@example.com', 'hash', 'bcrypt-12', $5, $5),
+		($2, 'Outdated', 'outdated@example.com', 'hash', 'bcrypt-10', $6, $6),
+		($3, 'Legacy', 'legacy@example.com', 'hash', NULL, $7, $7),
+		($4, 'Passwordless', 'passwordless@example.com', NULL, NULL, $7, $7)`,
+		current, outdated, legacy, passwordless, now.Add(-3*time.Minute), now.Add(-2*time.Minute), now.Add(-time.Minute))
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	users, err := userDB.ListHashesNeedingUpgrade(context.Background(), "bcrypt-12", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, outdated, users[0].ID)
+		assert.Equal(t, legacy, users[1].ID)
+	}
+
+	err = userDB.RehashPassword(context.Background(), outdated, "newhash", "bcrypt-12")
+	assert.NoError(t, err)
+
+	users, err = userDB.ListHashesNeedingUpgrade(context.Background(), "bcrypt-12", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, legacy, users[0].ID)
+	}
+}
+
+func TestUserDB_WithHashAlgorithm(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn, WithHashAlgorithm("bcrypt-12"))
+
+	user := &domain.User{Name: "Alice", Email: "alice@example.com", PasswordHash: "hash"}
+	err := userDB.Create(context.Background(), user)
+	assert.NoError(t, err)
+
+	users, err := userDB.ListHashesNeedingUpgrade(context.Background(), "bcrypt-12", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	_, err = conn.Exec(context.Background(), `UPDATE users SET hash_algorithm = 'bcrypt-10' WHERE id = $1`, user.ID)
+	assert.NoError(t, err)
+
+	err = userDB.UpdatePassword(context.Background(), user.ID, "newhash")
+	assert.NoError(t, err)
+
+	users, err = userDB.ListHashesNeedingUpgrade(context.Background(), "bcrypt-12", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestUserDB_SearchByEmail(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	for _, email := range []string{"bob@example.com", "alice@example.com", "a_b@example.com", "Alan@example.com", "alfred@example.com"} {
+		_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), "User", email, "hashedpassword", time.Now(), time.Now())
+		assert.NoError(t, err)
+	}
+
+	userDB := NewUserDB(conn)
+
+	emails := func(users []*domain.User) []string {
+		var result []string
+		for _, user := range users {
+			result = append(result, user.Email)
+		}
+		return result
+	}
+
+	users, err := userDB.SearchByEmail(context.Background(), "AL", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Alan@example.com", "alfred@example.com", "alice@example.com"}, emails(users))
+	for _, user := range users {
+		assert.Empty(t, user.PasswordHash)
+	}
+
+	// LIKE wildcards in the prefix match literally
+	users, err = userDB.SearchByEmail(context.Background(), "a_", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a_b@example.com"}, emails(users))
+
+	users, err = userDB.SearchByEmail(context.Background(), "al", 1)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	users, err = userDB.SearchByEmail(context.Background(), "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestUserDB_ListDormantUsers(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	now := time.Now()
+	active, expiredOnly, revokedOnly, noTokens := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES
+		($1, 'Active', 'active@example.com', 'hashedpassword', $5, $5),
+		($2, 'Expired', 'expired@example.com', 'hashedpassword', $6, $6),
+		($3, 'Revoked', 'revoked@example.com', 'hashedpassword', $7, $7),
+		($4, 'None', 'none@example.com', 'hashedpassword', $8, $8)`,
+		active, expiredOnly, revokedOnly, noTokens, now.Add(-4*time.Hour), now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Hour))
+	assert.NoError(t, err)
+	_, err = conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, revoked) VALUES
+		(gen_random_uuid(), $1, 'live', $4, false),
+		(gen_random_uuid(), $1, 'old', $5, false),
+		(gen_random_uuid(), $2, 'expired', $5, false),
+		(gen_random_uuid(), $3, 'revoked', $4, true)`,
+		active, expiredOnly, revokedOnly, now.Add(time.Hour), now.Add(-time.Hour))
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	users, err := userDB.ListDormantUsers(context.Background(), now, 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, users, 3) {
+		assert.Equal(t, expiredOnly, users[0].ID)
+		assert.Equal(t, revokedOnly, users[1].ID)
+		assert.Equal(t, noTokens, users[2].ID)
+	}
+
+	users, err = userDB.ListDormantUsers(context.Background(), now, 10, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, noTokens, users[0].ID)
+	}
+}
+
 func TestUserDB_Delete(t *testing.T) {
 	conn, teardown := setupPostgres(t)
 	defer teardown()
@@ -198,3 +643,578 @@ func TestUserDB_Delete(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, pgx.ErrNoRows))
 }
+
+func TestUserDB_DeleteIfNoActiveSessions(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	now := time.Now()
+	active, dormant := uuid.New(), uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES
+		($1, 'Active', 'active@example.com', 'hashedpassword', $3, $3),
+		($2, 'Dormant', 'dormant@example.com', 'hashedpassword', $3, $3)`,
+		active, dormant, now)
+	assert.NoError(t, err)
+	_, err = conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, revoked) VALUES
+		($1, $3, 'live', $5, false),
+		($2, $4, 'expired', $6, false),
+		(gen_random_uuid(), $4, 'revoked', $5, true)`,
+		uuid.New(), uuid.New(), active, dormant, now.Add(time.Hour), now.Add(-time.Hour))
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	err = userDB.DeleteIfNoActiveSessions(context.Background(), active, now)
+	assert.ErrorIs(t, err, ErrUserHasActiveSessions)
+	_, err = userDB.Read(context.Background(), active)
+	assert.NoError(t, err)
+
+	err = userDB.DeleteIfNoActiveSessions(context.Background(), dormant, now)
+	assert.NoError(t, err)
+	_, err = userDB.Read(context.Background(), dormant)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	// The expired and revoked tokens go with the user
+	var remaining int
+	err = conn.QueryRow(context.Background(), `SELECT count(*) FROM refresh_tokens WHERE user_id = $1`, dormant).Scan(&remaining)
+	assert.NoError(t, err)
+	assert.Zero(t, remaining)
+
+	err = userDB.DeleteIfNoActiveSessions(context.Background(), dormant, now)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestUserDB_DeleteIfNoActiveSessions_ConcurrentLogin(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	_, err := conn.Exec(ctx, `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		userID, "Alice", "alice@example.com", "hashedpassword", time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	// A login inserts its token but has not committed yet
+	tx, err := conn.Begin(ctx)
+	assert.NoError(t, err)
+	defer tx.Rollback(ctx)
+	err = NewRefreshTokenDB(tx).Create(ctx, &domain.RefreshToken{UserID: userID, RefreshToken: "live", ExpiresAt: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- NewUserDB(conn).DeleteIfNoActiveSessions(ctx, userID, time.Now())
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	assert.NoError(t, tx.Commit(ctx))
+
+	assert.ErrorIs(t, <-done, ErrUserHasActiveSessions)
+	_, err = NewUserDB(conn).Read(ctx, userID)
+	assert.NoError(t, err)
+}
+
+func TestUserDB_ImportBatch(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn)
+
+	// A user created outside the batch must survive the rollback
+	err := userDB.Create(context.Background(), &domain.User{Name: "Carol", Email: "carol@example.com", PasswordHash: "hashedpassword"})
+	assert.NoError(t, err)
+
+	batchID := uuid.New()
+	err = userDB.CreateBatch(context.Background(), batchID, []*domain.User{
+		{Name: "Alice", Email: "alice@example.com", PasswordHash: "hashedpassword"},
+		{Name: "Bob", Email: "bob@example.com", PasswordHash: "hashedpassword"},
+	})
+	assert.NoError(t, err)
+
+	users, err := userDB.ListByImportBatch(context.Background(), batchID)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	err = NewRefreshTokenDB(conn).Create(context.Background(), &domain.RefreshToken{
+		UserID:       users[0].ID,
+		RefreshToken: "imported_refresh_token",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	assert.NoError(t, err)
+
+	deleted, err := userDB.DeleteByImportBatch(context.Background(), batchID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	var tokens int
+	err = conn.QueryRow(context.Background(), `SELECT count(*) FROM refresh_tokens`).Scan(&tokens)
+	assert.NoError(t, err)
+	assert.Zero(t, tokens)
+
+	users, err = userDB.ListByImportBatch(context.Background(), batchID)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	user, err := userDB.ReadByEmail(context.Background(), "carol@example.com")
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+
+	// A duplicate email fails the batch with the typed error
+	err = userDB.CreateBatch(context.Background(), uuid.New(), []*domain.User{
+		{Name: "Carol", Email: "carol@example.com", PasswordHash: "hashedpassword"},
+	})
+	assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+	assert.NotContains(t, err.Error(), "carol@example.com")
+}
+
+func TestUserDB_ScanNormalization(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		userID, " Alice ", " Alice@Example.COM ", "hashedpassword", time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	user, err := NewUserDB(conn).Read(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, " Alice@Example.COM ", user.Email)
+
+	user, err = NewUserDB(conn, WithScanNormalization()).Read(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", user.Email)
+	assert.Equal(t, "Alice", user.Name)
+}
+
+func TestUserDB_ListByRecentActivity(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	now := time.Now()
+	ids := map[string]uuid.UUID{}
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		ids[name] = uuid.New()
+		_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			ids[name], name, strings.ToLower(name)+"@example.com", "hashedpassword", now, now)
+		assert.NoError(t, err)
+	}
+
+	// Bob refreshed most recently, Alice earlier, Carol never logged in
+	tokens := []struct {
+		user      string
+		createdAt time.Time
+	}{
+		{"Alice", now.Add(-2 * time.Hour)},
+		{"Bob", now.Add(-3 * time.Hour)},
+		{"Bob", now.Add(-time.Minute)},
+	}
+	for i, tok := range tokens {
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), ids[tok.user], fmt.Sprintf("refresh_token_%d", i), now.Add(24*time.Hour), tok.createdAt, tok.createdAt)
+		assert.NoError(t, err)
+	}
+
+	userDB := NewUserDB(conn)
+
+	users, err := userDB.ListByRecentActivity(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+	assert.Equal(t, "Bob", users[0].Name)
+	assert.Equal(t, "Alice", users[1].Name)
+	assert.Equal(t, "Carol", users[2].Name)
+}
+
+func TestUserDB_CountCreatedSince(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	now := time.Now()
+	createdAt := []time.Time{now.Add(-10 * time.Minute), now.Add(-30 * time.Minute), now.Add(-2 * time.Hour), now.Add(-48 * time.Hour)}
+	for i, ts := range createdAt {
+		_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), "User", fmt.Sprintf("user%d@example.com", i), "hashedpassword", ts, ts)
+		assert.NoError(t, err)
+	}
+
+	userDB := NewUserDB(conn)
+
+	count, err := userDB.CountCreatedSince(context.Background(), now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestUserDB_ListOlderThan(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	now := time.Now()
+	createdAt := []time.Time{now.Add(-400 * 24 * time.Hour), now.Add(-200 * 24 * time.Hour), now.Add(-10 * 24 * time.Hour)}
+	ids := make([]uuid.UUID, len(createdAt))
+	for i, ts := range createdAt {
+		ids[i] = uuid.New()
+		_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			ids[i], "User", fmt.Sprintf("user%d@example.com", i), "hashedpassword", ts, ts)
+		assert.NoError(t, err)
+	}
+
+	userDB := NewUserDB(conn)
+
+	users, err := userDB.ListOlderThan(context.Background(), 90*24*time.Hour, now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, ids[0], users[0].ID)
+		assert.Equal(t, ids[1], users[1].ID)
+	}
+
+	users, err = userDB.ListOlderThan(context.Background(), 90*24*time.Hour, now, 1)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func TestUserDB_ListPasswordless(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	withPassword, nullPassword, emptyPassword := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES
+		($1, 'Alice', 'alice@example.com', 'hashedpassword', $4, $4),
+		($2, 'Bob', 'bob@example.com', NULL, $5, $5),
+		($3, 'Carol', 'carol@example.com', '', $6, $6)`,
+		withPassword, nullPassword, emptyPassword, now.Add(-3*time.Minute), now.Add(-2*time.Minute), now.Add(-time.Minute))
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	users, err := userDB.ListPasswordless(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, nullPassword, users[0].ID)
+		assert.Equal(t, "", users[0].PasswordHash)
+		assert.Equal(t, emptyPassword, users[1].ID)
+	}
+
+	users, err = userDB.ListPasswordless(context.Background(), 10, 1)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	// A passwordless user reads back with an empty hash
+	user, err := userDB.Read(context.Background(), nullPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, "", user.PasswordHash)
+
+	user, err = userDB.ReadByEmail(context.Background(), "bob@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, nullPassword, user.ID)
+	assert.Equal(t, "", user.PasswordHash)
+}
+
+func TestUserDB_List(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	// Two users share a timestamp so the id tiebreaker is exercised
+	base := time.Now().Add(-time.Hour)
+	createdAt := []time.Time{base, base.Add(time.Minute), base.Add(time.Minute), base.Add(2 * time.Minute), base.Add(3 * time.Minute)}
+	for i, ts := range createdAt {
+		_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), fmt.Sprintf("User %d", i), fmt.Sprintf("user%d@example.com", i), "hashedpassword", ts, ts)
+		assert.NoError(t, err)
+	}
+
+	userDB := NewUserDB(conn)
+
+	all, err := userDB.List(context.Background(), 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, all, 5)
+	for _, user := range all {
+		assert.Empty(t, user.PasswordHash)
+	}
+
+	var paged []*domain.User
+	for offset := 0; offset < 5; offset += 2 {
+		page, err := userDB.List(context.Background(), 2, offset)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(page), 2)
+		paged = append(paged, page...)
+	}
+	if assert.Len(t, paged, 5) {
+		for i := range all {
+			assert.Equal(t, all[i].ID, paged[i].ID)
+		}
+	}
+	assert.False(t, all[0].CreatedAt.After(all[1].CreatedAt))
+}
+
+func TestUserDB_CreateBatchPartial(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn)
+
+	users := []*domain.User{
+		{Name: "Alice", Email: "alice@example.com", PasswordHash: "hashedpassword"},
+		{Name: "Alice Again", Email: "alice@example.com", PasswordHash: "hashedpassword"},
+		{Name: "Bob", Email: "bob@example.com", PasswordHash: "hashedpassword"},
+	}
+
+	inserted, failures, err := userDB.CreateBatchPartial(context.Background(), users)
+	assert.NoError(t, err)
+	assert.Len(t, inserted, 2)
+	assert.Len(t, failures, 1)
+	assert.ErrorIs(t, failures[1], ErrEmailAlreadyExists)
+
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		user, err := userDB.ReadByEmail(context.Background(), email)
+		assert.NoError(t, err)
+		assert.NotNil(t, user)
+	}
+}
+
+func TestUserDB_ExportUserData(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, phone, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		userID, "Alice", "alice@example.com", "hashedpassword", "+4915112345678", time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	_, err = conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), userID, "example_refresh_token", time.Now().Add(time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	export, err := userDB.ExportUserData(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, export.Profile.ID)
+	assert.Equal(t, "alice@example.com", export.Profile.Email)
+	if assert.NotNil(t, export.Profile.Phone) {
+		assert.Equal(t, "+4915112345678", *export.Profile.Phone)
+	}
+	assert.Len(t, export.Sessions, 1)
+
+	// Verify the serialized bundle has the expected sections and no secrets
+	data, err := json.Marshal(export)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"profile"`)
+	assert.Contains(t, string(data), `"sessions"`)
+	assert.Contains(t, string(data), `"phone":"+4915112345678"`)
+	assert.NotContains(t, string(data), "hashedpassword")
+	assert.NotContains(t, string(data), "example_refresh_token")
+}
+
+func TestUserDB_UpdatePhone(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		userID, "Alice", "alice@example.com", "hashedpassword", time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	user, err := userDB.Read(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Nil(t, user.Phone)
+
+	err = userDB.UpdatePhone(context.Background(), userID, "+14155552671")
+	assert.NoError(t, err)
+
+	user, err = userDB.Read(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.NotNil(t, user.Phone)
+	assert.Equal(t, "+14155552671", *user.Phone)
+
+	err = userDB.UpdatePhone(context.Background(), userID, "555-2671")
+	assert.ErrorIs(t, err, domain.ErrInvalidPhone)
+}
+
+func TestUserDB_EmailDomain(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	emails := []string{"alice@acme.com", "bob@ACME.com", "carol@example.com", "dave@acme.com.evil.org", "eve@acmexcom"}
+	for i, email := range emails {
+		_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), fmt.Sprintf("User %d", i), email, "hashedpassword", time.Now(), time.Now())
+		assert.NoError(t, err)
+	}
+
+	userDB := NewUserDB(conn)
+
+	// The rows were inserted without email_domain, like rows predating it
+	filled, err := userDB.BackfillEmailDomains(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(emails)), filled)
+
+	rows, err := conn.Query(context.Background(), `SELECT email, email_domain FROM users`)
+	assert.NoError(t, err)
+	for rows.Next() {
+		var email, emailDomain string
+		assert.NoError(t, rows.Scan(&email, &emailDomain))
+		assert.Equal(t, domain.EmailDomain(email), emailDomain)
+	}
+	rows.Close()
+
+	filled, err = userDB.BackfillEmailDomains(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Zero(t, filled)
+
+	count, err := userDB.CountByEmailDomain(context.Background(), "acme.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = userDB.CountByEmailDomain(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	users, err := userDB.ListByEmailDomain(context.Background(), "acme.com", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "alice@acme.com", users[0].Email)
+
+	// "_" must not act as a wildcard
+	count, err = userDB.CountByEmailDomain(context.Background(), "acme_com")
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Writes through the repository keep the column in sync
+	user := &domain.User{Name: "Frank", Email: "frank@Acme.com", PasswordHash: "hashedpassword"}
+	err = userDB.Create(context.Background(), user)
+	assert.NoError(t, err)
+	count, err = userDB.CountByEmailDomain(context.Background(), "ACME.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	err = userDB.UpdateEmail(context.Background(), user.ID, "frank@example.com")
+	assert.NoError(t, err)
+	count, err = userDB.CountByEmailDomain(context.Background(), "acme.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestUserDB_ListRecent(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		createdAt := now.Add(time.Duration(i) * time.Minute)
+		_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.New(), fmt.Sprintf("User %d", i), fmt.Sprintf("user%d@example.com", i), "hashedpassword", createdAt, createdAt)
+		assert.NoError(t, err)
+	}
+
+	userDB := NewUserDB(conn)
+
+	users, err := userDB.ListRecent(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+	assert.Equal(t, "User 4", users[0].Name)
+	assert.Equal(t, "User 3", users[1].Name)
+	assert.Equal(t, "User 2", users[2].Name)
+	for _, user := range users {
+		assert.Empty(t, user.PasswordHash)
+	}
+}
+
+func TestUserDB_CreateSeatLimit(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn, WithSeatLimit(2))
+
+	for i := 0; i < 2; i++ {
+		err := userDB.Create(context.Background(), &domain.User{
+			Name:         fmt.Sprintf("User %d", i),
+			Email:        fmt.Sprintf("user%d@example.com", i),
+			PasswordHash: "hashedpassword",
+		})
+		assert.NoError(t, err)
+	}
+
+	err := userDB.Create(context.Background(), &domain.User{
+		Name:         "One Too Many",
+		Email:        "extra@example.com",
+		PasswordHash: "hashedpassword",
+	})
+	assert.ErrorIs(t, err, ErrSeatLimitReached)
+
+	// Without a limit the same insert succeeds
+	err = NewUserDB(conn).Create(context.Background(), &domain.User{
+		Name:         "One Too Many",
+		Email:        "extra@example.com",
+		PasswordHash: "hashedpassword",
+	})
+	assert.NoError(t, err)
+}
+
+func TestUserDB_CreateBatchSeatLimit(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userDB := NewUserDB(conn, WithSeatLimit(3))
+
+	newUsers := func(prefix string, n int) []*domain.User {
+		var users []*domain.User
+		for i := 0; i < n; i++ {
+			users = append(users, &domain.User{
+				Name:         fmt.Sprintf("User %d", i),
+				Email:        fmt.Sprintf("%s%d@example.com", prefix, i),
+				PasswordHash: "hashedpassword",
+			})
+		}
+		return users
+	}
+
+	// A batch that does not fit is rejected as a whole
+	err := userDB.CreateBatch(context.Background(), uuid.New(), newUsers("batch", 4))
+	assert.ErrorIs(t, err, ErrSeatLimitReached)
+
+	err = userDB.CreateBatch(context.Background(), uuid.New(), newUsers("batch", 2))
+	assert.NoError(t, err)
+
+	// A partial batch is cut off at the remaining seat
+	inserted, failures, err := userDB.CreateBatchPartial(context.Background(), newUsers("partial", 3))
+	assert.NoError(t, err)
+	assert.Len(t, inserted, 1)
+	assert.Len(t, failures, 2)
+	assert.ErrorIs(t, failures[1], ErrSeatLimitReached)
+	assert.ErrorIs(t, failures[2], ErrSeatLimitReached)
+
+	var count int
+	err = conn.QueryRow(context.Background(), `SELECT count(*) FROM users`).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestUserDB_ReadByEmailOrID(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		userID, "Alice", "alice@example.com", "hashedpassword", time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	userDB := NewUserDB(conn)
+
+	user, err := userDB.ReadByEmailOrID(context.Background(), userID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+
+	user, err = userDB.ReadByEmailOrID(context.Background(), "alice@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+
+	user, err = userDB.ReadByEmailOrID(context.Background(), "nobody@example.com")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.Nil(t, user)
+
+	user, err = userDB.ReadByEmailOrID(context.Background(), uuid.New().String())
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.Nil(t, user)
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// Check probes a single dependency and returns an error when it is not ready.
+type Check func(ctx context.Context) error
+
+type dependency struct {
+	name    string
+	check   Check
+	timeout time.Duration
+}
+
+// Readiness aggregates the health of several dependencies into one report.
+type Readiness struct {
+	dependencies []dependency
+}
+
+// ReadinessReport holds the per-dependency status ("ok" or the error message)
+// and whether every dependency is ready.
+type ReadinessReport struct {
+	Ready        bool
+	Dependencies map[string]string
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Add registers a dependency check bounded by its own timeout.
+func (r *Readiness) Add(name string, check Check, timeout time.Duration) *Readiness {
+	r.dependencies = append(r.dependencies, dependency{name: name, check: check, timeout: timeout})
+	return r
+}
+
+// Check runs every registered check concurrently.
+func (r *Readiness) Check(ctx context.Context) ReadinessReport {
+	report := ReadinessReport{
+		Ready:        true,
+		Dependencies: make(map[string]string, len(r.dependencies)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, dep := range r.dependencies {
+		wg.Add(1)
+		go func(dep dependency) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, dep.timeout)
+			defer cancel()
+			err := dep.check(checkCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Ready = false
+				report.Dependencies[dep.name] = err.Error()
+				return
+			}
+			report.Dependencies[dep.name] = "ok"
+		}(dep)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func PostgresCheck(db *pgxpool.Pool) Check {
+	return func(ctx context.Context) error {
+		if err := db.Ping(ctx); err != nil {
+			return fmt.Errorf("postgres ping failed: %w", err)
+		}
+		return nil
+	}
+}
+
+func RedisCheck(cache *redis.Client) Check {
+	return func(ctx context.Context) error {
+		if err := cache.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("redis ping failed: %w", err)
+		}
+		return nil
+	}
+}
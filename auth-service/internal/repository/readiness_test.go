@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Helper function to setup PostgreSQL container
+func setupPostgres(t *testing.T) (*pgxpool.Pool, func()) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:13",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_PASSWORD": "password",
+			"POSTGRES_USER":     "user",
+			"POSTGRES_DB":       "testdb",
+		},
+		WaitingFor: wait.ForListeningPort("5432/tcp"),
+	}
+	postgresContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	assert.NoError(t, err)
+
+	host, err := postgresContainer.Host(ctx)
+	assert.NoError(t, err)
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	assert.NoError(t, err)
+
+	dsn := "postgres://user:password@" + host + ":" + port.Port() + "/testdb?sslmode=disable"
+	conn, err := pgxpool.New(context.Background(), dsn)
+	assert.NoError(t, err)
+
+	teardown := func() {
+		conn.Close()
+		postgresContainer.Terminate(ctx)
+	}
+
+	return conn, teardown
+}
+
+func TestReadiness_RedisDown(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	// Nothing listens on this port, so the Redis check must fail
+	cache := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	defer cache.Close()
+
+	readiness := NewReadiness().
+		Add("postgres", PostgresCheck(conn), time.Second).
+		Add("redis", RedisCheck(cache), time.Second)
+
+	report := readiness.Check(context.Background())
+	assert.False(t, report.Ready)
+	assert.Equal(t, "ok", report.Dependencies["postgres"])
+	assert.NotEqual(t, "ok", report.Dependencies["redis"])
+}
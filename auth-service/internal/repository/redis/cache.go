@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrCacheMiss  = errors.New("cache miss")
+	ErrInvalidTTL = errors.New("cache ttl must be positive")
+)
+
+// Cache is a key-value store with per-key expiry. Get returns ErrCacheMiss
+// when the key is absent or expired; Take does the same but atomically
+// deletes the key it returns. Sets are used for secondary indexes;
+// AddToSet extends the set's expiry to at least ttl and RemoveFromSet leaves
+// it unchanged.
+type Cache interface {
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Take(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, keys ...string) error
+	AddToSet(ctx context.Context, key, member string, ttl time.Duration) error
+	RemoveFromSet(ctx context.Context, key, member string) error
+	SetMembers(ctx context.Context, key string) ([]string, error)
+}
+
+// RedisCache implements Cache on top of a Redis client.
+type RedisCache struct {
+	client *redis.Client
+}
+
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{
+		client: client,
+	}
+}
+
+func (c *RedisCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.client.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", ErrCacheMiss
+		}
+		return "", err
+	}
+
+	return value, nil
+}
+
+func (c *RedisCache) Take(ctx context.Context, key string) (string, error) {
+	value, err := c.client.GetDel(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", ErrCacheMiss
+		}
+		return "", err
+	}
+
+	return value, nil
+}
+
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return c.client.Del(ctx, keys...).Err()
+}
+
+func (c *RedisCache) AddToSet(ctx context.Context, key, member string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	// NX sets the expiry of a fresh set, GT only ever extends an existing one.
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, member)
+		pipe.ExpireNX(ctx, key, ttl)
+		pipe.ExpireGT(ctx, key, ttl)
+		return nil
+	})
+
+	return err
+}
+
+func (c *RedisCache) RemoveFromSet(ctx context.Context, key, member string) error {
+	return c.client.SRem(ctx, key, member).Err()
+}
+
+func (c *RedisCache) SetMembers(ctx context.Context, key string) ([]string, error) {
+	return c.client.SMembers(ctx, key).Result()
+}
+
+type memoryEntry struct {
+	value     string
+	members   map[string]struct{}
+	expiresAt time.Time
+}
+
+// MemoryCache is an in-process Cache, meant for tests and single-instance
+// deployments.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = memoryEntry{value: value, expiresAt: c.now().Add(ttl)}
+
+	return nil
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", ErrCacheMiss
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return "", ErrCacheMiss
+	}
+
+	return entry.value, nil
+}
+
+func (c *MemoryCache) Take(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", ErrCacheMiss
+	}
+	delete(c.entries, key)
+	if !c.now().Before(entry.expiresAt) {
+		return "", ErrCacheMiss
+	}
+
+	return entry.value, nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) AddToSet(ctx context.Context, key, member string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		entry = memoryEntry{members: make(map[string]struct{})}
+	}
+	entry.members[member] = struct{}{}
+	if expiresAt := now.Add(ttl); expiresAt.After(entry.expiresAt) {
+		entry.expiresAt = expiresAt
+	}
+	c.entries[key] = entry
+
+	return nil
+}
+
+func (c *MemoryCache) RemoveFromSet(ctx context.Context, key, member string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return nil
+	}
+	delete(entry.members, member)
+	if len(entry.members) == 0 {
+		delete(c.entries, key)
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) SetMembers(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return nil, nil
+	}
+
+	members := make([]string, 0, len(entry.members))
+	for member := range entry.members {
+		members = append(members, member)
+	}
+
+	return members, nil
+}
@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCacheConformance checks the behaviour every Cache implementation must
+// share.
+func testCacheConformance(t *testing.T, cache Cache) {
+	ctx := context.Background()
+
+	t.Run("miss", func(t *testing.T) {
+		_, err := cache.Get(ctx, "missing")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		err := cache.Set(ctx, "key", "value", time.Minute)
+		assert.NoError(t, err)
+
+		value, err := cache.Get(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := cache.Set(ctx, "deleted", "value", time.Minute)
+		assert.NoError(t, err)
+
+		err = cache.Delete(ctx, "deleted")
+		assert.NoError(t, err)
+
+		_, err = cache.Get(ctx, "deleted")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("take", func(t *testing.T) {
+		err := cache.Set(ctx, "taken", "value", time.Minute)
+		assert.NoError(t, err)
+
+		value, err := cache.Take(ctx, "taken")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+
+		_, err = cache.Take(ctx, "taken")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+		_, err = cache.Get(ctx, "taken")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		err := cache.Set(ctx, "short", "value", 50*time.Millisecond)
+		assert.NoError(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+
+		_, err = cache.Get(ctx, "short")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("sets", func(t *testing.T) {
+		err := cache.AddToSet(ctx, "set", "a", time.Minute)
+		assert.NoError(t, err)
+		err = cache.AddToSet(ctx, "set", "b", time.Minute)
+		assert.NoError(t, err)
+
+		members, err := cache.SetMembers(ctx, "set")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a", "b"}, members)
+
+		members, err = cache.SetMembers(ctx, "missing-set")
+		assert.NoError(t, err)
+		assert.Empty(t, members)
+
+		err = cache.RemoveFromSet(ctx, "set", "a")
+		assert.NoError(t, err)
+		err = cache.RemoveFromSet(ctx, "set", "missing")
+		assert.NoError(t, err)
+		err = cache.RemoveFromSet(ctx, "missing-set", "a")
+		assert.NoError(t, err)
+
+		members, err = cache.SetMembers(ctx, "set")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b"}, members)
+	})
+
+	t.Run("delete many", func(t *testing.T) {
+		for _, key := range []string{"k1", "k2"} {
+			err := cache.Set(ctx, key, "value", time.Minute)
+			assert.NoError(t, err)
+		}
+
+		err := cache.Delete(ctx, "k1", "k2")
+		assert.NoError(t, err)
+
+		for _, key := range []string{"k1", "k2"} {
+			_, err = cache.Get(ctx, key)
+			assert.ErrorIs(t, err, ErrCacheMiss)
+		}
+	})
+
+	t.Run("non-positive ttl", func(t *testing.T) {
+		err := cache.Set(ctx, "invalid", "value", 0)
+		assert.ErrorIs(t, err, ErrInvalidTTL)
+	})
+}
+
+func TestMemoryCache_Conformance(t *testing.T) {
+	testCacheConformance(t, NewMemoryCache())
+}
+
+func TestRedisCache_Conformance(t *testing.T) {
+	client, teardown := setupRedis(t)
+	defer teardown()
+
+	testCacheConformance(t, NewRedisCache(client))
+}
@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+// RetryCache retries operations of the wrapped Cache that fail with a
+// connection-level error, e.g. during a Redis failover. Misses and other
+// errors are returned immediately.
+type RetryCache struct {
+	cache    Cache
+	attempts int
+	backoff  time.Duration
+}
+
+func NewRetryCache(cache Cache, attempts int, backoff time.Duration) *RetryCache {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	return &RetryCache{
+		cache:    cache,
+		attempts: attempts,
+		backoff:  backoff,
+	}
+}
+
+func (c *RetryCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return c.retry(ctx, func() error {
+		return c.cache.Set(ctx, key, value, ttl)
+	})
+}
+
+func (c *RetryCache) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := c.retry(ctx, func() error {
+		var err error
+		value, err = c.cache.Get(ctx, key)
+		return err
+	})
+
+	return value, err
+}
+
+// Take is attempted only once: after a connection error the key may already
+// have been deleted, and a retry would then report a miss for a value that
+// was consumed.
+func (c *RetryCache) Take(ctx context.Context, key string) (string, error) {
+	return c.cache.Take(ctx, key)
+}
+
+func (c *RetryCache) Delete(ctx context.Context, keys ...string) error {
+	return c.retry(ctx, func() error {
+		return c.cache.Delete(ctx, keys...)
+	})
+}
+
+func (c *RetryCache) AddToSet(ctx context.Context, key, member string, ttl time.Duration) error {
+	return c.retry(ctx, func() error {
+		return c.cache.AddToSet(ctx, key, member, ttl)
+	})
+}
+
+func (c *RetryCache) RemoveFromSet(ctx context.Context, key, member string) error {
+	return c.retry(ctx, func() error {
+		return c.cache.RemoveFromSet(ctx, key, member)
+	})
+}
+
+func (c *RetryCache) SetMembers(ctx context.Context, key string) ([]string, error) {
+	var members []string
+	err := c.retry(ctx, func() error {
+		var err error
+		members, err = c.cache.SetMembers(ctx, key)
+		return err
+	})
+
+	return members, err
+}
+
+func (c *RetryCache) retry(ctx context.Context, op func() error) error {
+	var err error
+	for attempt := 0; attempt < c.attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(c.backoff * time.Duration(attempt)):
+			}
+		}
+
+		err = op()
+		if !isTransient(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// isTransient reports whether err is a connection-level failure worth
+// retrying. Context cancellation and deadlines are never retried.
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
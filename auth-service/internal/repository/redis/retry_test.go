@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyCache fails the first failures calls with err before delegating.
+type flakyCache struct {
+	Cache
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyCache) Get(ctx context.Context, key string) (string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return "", f.err
+	}
+	return f.Cache.Get(ctx, key)
+}
+
+func (f *flakyCache) Take(ctx context.Context, key string) (string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return "", f.err
+	}
+	return f.Cache.Take(ctx, key)
+}
+
+func TestRetryCache_RetriesTransientErrors(t *testing.T) {
+	memory := NewMemoryCache()
+	err := memory.Set(context.Background(), "key", "value", time.Minute)
+	assert.NoError(t, err)
+
+	flaky := &flakyCache{Cache: memory, failures: 1, err: syscall.ECONNRESET}
+	cache := NewRetryCache(flaky, 3, time.Millisecond)
+
+	value, err := cache.Get(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, 2, flaky.calls)
+}
+
+func TestRetryCache_DoesNotRetryMissOrPermanentErrors(t *testing.T) {
+	flaky := &flakyCache{Cache: NewMemoryCache()}
+	cache := NewRetryCache(flaky, 3, time.Millisecond)
+
+	_, err := cache.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+	assert.Equal(t, 1, flaky.calls)
+
+	permanent := errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	flaky = &flakyCache{Cache: NewMemoryCache(), failures: 3, err: permanent}
+	cache = NewRetryCache(flaky, 3, time.Millisecond)
+
+	_, err = cache.Get(context.Background(), "key")
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestRetryCache_DoesNotRetryTake(t *testing.T) {
+	memory := NewMemoryCache()
+	err := memory.Set(context.Background(), "key", "value", time.Minute)
+	assert.NoError(t, err)
+
+	flaky := &flakyCache{Cache: memory, failures: 1, err: syscall.ECONNRESET}
+	cache := NewRetryCache(flaky, 3, time.Millisecond)
+
+	_, err = cache.Take(context.Background(), "key")
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+	assert.Equal(t, 1, flaky.calls)
+}
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrStepUpInvalid is returned by VerifyStepUp for a token that is unknown,
+// expired, already used or issued to another user.
+var ErrStepUpInvalid = errors.New("step-up token invalid")
+
+// StepUpStore issues short-lived, single-use tokens proving that a user has
+// recently re-authenticated, for sensitive actions like deleting the account
+// or changing the email.
+type StepUpStore struct {
+	cache Cache
+}
+
+func NewStepUpStore(cache Cache) *StepUpStore {
+	return &StepUpStore{
+		cache: cache,
+	}
+}
+
+// IssueStepUp returns a new random token for the user that VerifyStepUp
+// accepts once within ttl.
+func (s *StepUpStore) IssueStepUp(ctx context.Context, userID uuid.UUID, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate step-up token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	if err := s.cache.Set(ctx, stepUpKey(token), userID.String(), ttl); err != nil {
+		return "", fmt.Errorf("failed to store step-up token: %w", err)
+	}
+
+	return token, nil
+}
+
+// VerifyStepUp consumes the token and checks that it was issued to the user.
+// A token is consumed even when presented for the wrong user, so it can never
+// be replayed.
+func (s *StepUpStore) VerifyStepUp(ctx context.Context, userID uuid.UUID, token string) error {
+	owner, err := s.cache.Take(ctx, stepUpKey(token))
+	if err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			return ErrStepUpInvalid
+		}
+		return fmt.Errorf("failed to verify step-up token: %w", err)
+	}
+
+	if owner != userID.String() {
+		return ErrStepUpInvalid
+	}
+
+	return nil
+}
+
+func stepUpKey(token string) string {
+	return "auth:stepup:" + token
+}
@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepUpStore_IssueVerify(t *testing.T) {
+	store := NewStepUpStore(NewMemoryCache())
+	userID := uuid.New()
+
+	token, err := store.IssueStepUp(context.Background(), userID, time.Minute)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	err = store.VerifyStepUp(context.Background(), userID, token)
+	assert.NoError(t, err)
+
+	// A replay of the same token is rejected
+	err = store.VerifyStepUp(context.Background(), userID, token)
+	assert.ErrorIs(t, err, ErrStepUpInvalid)
+}
+
+func TestStepUpStore_Rejects(t *testing.T) {
+	cache := NewMemoryCache()
+	store := NewStepUpStore(cache)
+	userID := uuid.New()
+
+	err := store.VerifyStepUp(context.Background(), userID, "unknown")
+	assert.ErrorIs(t, err, ErrStepUpInvalid)
+
+	token, err := store.IssueStepUp(context.Background(), userID, time.Minute)
+	assert.NoError(t, err)
+	err = store.VerifyStepUp(context.Background(), uuid.New(), token)
+	assert.ErrorIs(t, err, ErrStepUpInvalid)
+
+	token, err = store.IssueStepUp(context.Background(), userID, time.Minute)
+	assert.NoError(t, err)
+	cache.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	err = store.VerifyStepUp(context.Background(), userID, token)
+	assert.ErrorIs(t, err, ErrStepUpInvalid)
+}
@@ -2,21 +2,142 @@ package redis
 
 import (
 	"context"
-	"github.com/redis/go-redis/v9"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"time"
 	"todoservice/auth-service/internal/domain"
+
+	"github.com/google/uuid"
 )
 
+var ErrCacheTimeout = errors.New("cache operation timed out")
+
 type TokenCache struct {
-	cache *redis.Client
+	cache    Cache
+	failOpen bool
+}
+
+// Option configures a TokenCache.
+type Option func(*TokenCache)
+
+// WithFailOpen makes cache timeouts non-fatal: the operation is skipped and
+// no error is returned, so a slow cache never fails the request.
+func WithFailOpen() Option {
+	return func(t *TokenCache) {
+		t.failOpen = true
+	}
 }
 
-func NewTokenCache(cache *redis.Client) *TokenCache {
-	return &TokenCache{
+func NewTokenCache(cache Cache, opts ...Option) *TokenCache {
+	t := &TokenCache{
 		cache: cache,
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
 }
 
+// Set caches the whole token as JSON until it expires and records it in the
+// owner's token index so all of a user's entries can be purged at once. An
+// already expired token is rejected with ErrInvalidTTL.
 func (t *TokenCache) Set(ctx context.Context, token *domain.RefreshToken) error {
-	return t.cache.Set(ctx, token.ID.String(), token.RefreshToken, token.ExpiresAt.Sub(time.Now())).Err()
+	ttl := token.ExpiresAt.Sub(time.Now())
+	if ttl <= 0 {
+		return fmt.Errorf("%w: token already expired", ErrInvalidTTL)
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+
+	if err := t.cache.Set(ctx, token.ID.String(), string(data), ttl); err != nil {
+		return t.handleError(err)
+	}
+
+	err = t.cache.AddToSet(ctx, userTokensKey(token.UserID), token.ID.String(), ttl)
+	return t.handleError(err)
+}
+
+// Get returns the cached token. A missing or expired entry yields
+// ErrCacheMiss unwrapped, so callers can fall through to Postgres; with
+// WithFailOpen a timeout is reported as a miss as well.
+func (t *TokenCache) Get(ctx context.Context, tokenID uuid.UUID) (*domain.RefreshToken, error) {
+	data, err := t.cache.Get(ctx, tokenID.String())
+	if err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			return nil, ErrCacheMiss
+		}
+		if err = t.handleError(err); err == nil {
+			return nil, ErrCacheMiss
+		}
+		return nil, err
+	}
+
+	var token domain.RefreshToken
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, fmt.Errorf("failed to decode cached token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// Delete removes a single cached token, e.g. on logout, and drops it from
+// the owner's token index. Deleting a token that is not cached is not an
+// error.
+func (t *TokenCache) Delete(ctx context.Context, token *domain.RefreshToken) error {
+	if err := t.cache.Delete(ctx, token.ID.String()); err != nil {
+		return t.handleError(err)
+	}
+
+	err := t.cache.RemoveFromSet(ctx, userTokensKey(token.UserID), token.ID.String())
+	return t.handleError(err)
+}
+
+// DeleteAllForUser removes every cached token of the user together with the
+// user's token index. It takes two round trips: one to read the index and a
+// single delete for all the keys.
+func (t *TokenCache) DeleteAllForUser(ctx context.Context, userID uuid.UUID) error {
+	key := userTokensKey(userID)
+	tokenIDs, err := t.cache.SetMembers(ctx, key)
+	if err != nil {
+		return t.handleError(err)
+	}
+
+	err = t.cache.Delete(ctx, append(tokenIDs, key)...)
+	return t.handleError(err)
+}
+
+func userTokensKey(userID uuid.UUID) string {
+	return "auth:user:" + userID.String() + ":tokens"
+}
+
+// handleError maps deadline and network timeouts to ErrCacheTimeout and
+// swallows them when the cache is configured to fail open.
+func (t *TokenCache) handleError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if isTimeout(err) {
+		if t.failOpen {
+			return nil
+		}
+		return fmt.Errorf("%w: %v", ErrCacheTimeout, err)
+	}
+
+	return fmt.Errorf("cache operation failed: %w", err)
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"todoservice/auth-service/internal/domain"
+)
+
+// Helper function to setup Redis container
+func setupRedis(t *testing.T) (*redis.Client, func()) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "redis:7",
+		ExposedPorts: []string{"6379/tcp"},
+		WaitingFor:   wait.ForListeningPort("6379/tcp"),
+	}
+	redisContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	assert.NoError(t, err)
+
+	host, err := redisContainer.Host(ctx)
+	assert.NoError(t, err)
+
+	port, err := redisContainer.MappedPort(ctx, "6379")
+	assert.NoError(t, err)
+
+	client := redis.NewClient(&redis.Options{Addr: host + ":" + port.Port()})
+
+	teardown := func() {
+		client.Close()
+		redisContainer.Terminate(ctx)
+	}
+
+	return client, teardown
+}
+
+func TestTokenCache_SetTimeout(t *testing.T) {
+	client, teardown := setupRedis(t)
+	defer teardown()
+
+	token := &domain.RefreshToken{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		RefreshToken: "example_refresh_token",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+
+	err := NewTokenCache(NewRedisCache(client)).Set(ctx, token)
+	assert.ErrorIs(t, err, ErrCacheTimeout)
+
+	err = NewTokenCache(NewRedisCache(client), WithFailOpen()).Set(ctx, token)
+	assert.NoError(t, err)
+}
+
+func TestTokenCache_GetDelete(t *testing.T) {
+	client, teardown := setupRedis(t)
+	defer teardown()
+
+	tokenCache := NewTokenCache(NewRedisCache(client))
+
+	token := &domain.RefreshToken{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		RefreshToken: "sample_refresh_token",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+	err := tokenCache.Set(context.Background(), token)
+	assert.NoError(t, err)
+
+	cached, err := tokenCache.Get(context.Background(), token.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "sample_refresh_token", cached.RefreshToken)
+
+	err = tokenCache.Delete(context.Background(), token)
+	assert.NoError(t, err)
+
+	_, err = tokenCache.Get(context.Background(), token.ID)
+	assert.Equal(t, ErrCacheMiss, err)
+
+	members, err := client.SMembers(context.Background(), userTokensKey(token.UserID)).Result()
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+
+	// Deleting an absent entry is a no-op
+	err = tokenCache.Delete(context.Background(), token)
+	assert.NoError(t, err)
+}
+
+func TestTokenCache_DeleteAllForUser(t *testing.T) {
+	client, teardown := setupRedis(t)
+	defer teardown()
+
+	cache := NewRedisCache(client)
+	tokenCache := NewTokenCache(cache)
+
+	userID := uuid.New()
+	tokens := []*domain.RefreshToken{
+		{ID: uuid.New(), UserID: userID, RefreshToken: "first_refresh_token", ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: uuid.New(), UserID: userID, RefreshToken: "second_refresh_token", ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: uuid.New(), UserID: uuid.New(), RefreshToken: "other_refresh_token", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	for _, token := range tokens {
+		err := tokenCache.Set(context.Background(), token)
+		assert.NoError(t, err)
+	}
+
+	err := tokenCache.DeleteAllForUser(context.Background(), userID)
+	assert.NoError(t, err)
+
+	for _, token := range tokens[:2] {
+		_, err = cache.Get(context.Background(), token.ID.String())
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	}
+
+	cached, err := tokenCache.Get(context.Background(), tokens[2].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "other_refresh_token", cached.RefreshToken)
+}
+
+func TestTokenCache_RoundTrip(t *testing.T) {
+	client, teardown := setupRedis(t)
+	defer teardown()
+
+	tokenCache := NewTokenCache(NewRedisCache(client))
+
+	token := &domain.RefreshToken{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		RefreshToken: "sample_refresh_token",
+		ExpiresAt:    time.Now().Add(time.Hour),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	err := tokenCache.Set(context.Background(), token)
+	assert.NoError(t, err)
+
+	cached, err := tokenCache.Get(context.Background(), token.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, cached.ID)
+	assert.Equal(t, token.UserID, cached.UserID)
+	assert.Equal(t, token.RefreshToken, cached.RefreshToken)
+	assert.WithinDuration(t, token.ExpiresAt, cached.ExpiresAt, time.Second)
+	assert.WithinDuration(t, token.CreatedAt, cached.CreatedAt, time.Second)
+
+	expired := &domain.RefreshToken{ID: uuid.New(), UserID: uuid.New(), RefreshToken: "expired", ExpiresAt: time.Now().Add(-time.Second)}
+	err = tokenCache.Set(context.Background(), expired)
+	assert.ErrorIs(t, err, ErrInvalidTTL)
+}
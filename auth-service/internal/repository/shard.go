@@ -0,0 +1,16 @@
+package repository
+
+import "hash/fnv"
+
+// ShardFor maps a token to one of shards buckets using FNV-1a, so the same
+// token always routes to the same shard. It returns 0 when shards < 1.
+func ShardFor(token string, shards int) int {
+	if shards < 1 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(token))
+
+	return int(h.Sum32() % uint32(shards))
+}
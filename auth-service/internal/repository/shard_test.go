@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardFor_Stable(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		token := fmt.Sprintf("token-%d", i)
+		assert.Equal(t, ShardFor(token, 8), ShardFor(token, 8))
+	}
+
+	assert.Equal(t, 0, ShardFor("token", 0))
+	assert.Equal(t, 0, ShardFor("token", 1))
+}
+
+func TestShardFor_Distribution(t *testing.T) {
+	const shards, tokens = 8, 80000
+
+	counts := make([]int, shards)
+	for i := 0; i < tokens; i++ {
+		shard := ShardFor(fmt.Sprintf("refresh-token-%d", i), shards)
+		assert.True(t, shard >= 0 && shard < shards)
+		counts[shard]++
+	}
+
+	// Every shard should be within 10% of the ideal share
+	expected := tokens / shards
+	for shard, count := range counts {
+		assert.InDelta(t, expected, count, float64(expected)/10, "shard %d", shard)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todoservice/auth-service/internal/auth"
+	"todoservice/auth-service/internal/domain"
+	"todoservice/auth-service/internal/repository/postgres"
+)
+
+// ErrSessionRevoked is returned by ValidateSession when the refresh token an
+// access token was issued with is gone, revoked or expired.
+var ErrSessionRevoked = errors.New("session revoked")
+
+// SessionService validates access tokens against the session store, so a
+// signature-valid token stops working as soon as its session ends.
+type SessionService struct {
+	manager *auth.TokenManager
+	users   *postgres.UserDB
+	tokens  *postgres.RefreshTokenDB
+	now     func() time.Time
+}
+
+// NewSessionService returns a service validating tokens issued by manager.
+// The options are applied to the repositories it creates.
+func NewSessionService(db postgres.DBTX, manager *auth.TokenManager, opts ...postgres.Option) *SessionService {
+	return &SessionService{
+		manager: manager,
+		users:   postgres.NewUserDB(db, opts...),
+		tokens:  postgres.NewRefreshTokenDB(db, opts...),
+		now:     time.Now,
+	}
+}
+
+// ValidateSession checks the access token's signature and expiry, then that
+// the session in its jti claim is still a valid refresh token of the same
+// user, and returns that user.
+func (s *SessionService) ValidateSession(ctx context.Context, accessToken string) (*domain.User, error) {
+	userID, sessionID, err := s.manager.ParseSessionAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.tokens.Read(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrRefreshTokenNotFound) {
+			return nil, ErrSessionRevoked
+		}
+		return nil, err
+	}
+
+	if session.UserID != userID || session.Status(s.now()) != domain.TokenValid {
+		return nil, ErrSessionRevoked
+	}
+
+	return s.users.Read(ctx, userID)
+}
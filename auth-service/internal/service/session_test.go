@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"todoservice/auth-service/internal/auth"
+)
+
+func TestSessionService_ValidateSession(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userID, live, revoked := uuid.New(), uuid.New(), uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO users (id, name, email, password_hash) VALUES ($1, 'Alice', 'alice@example.com', 'hashedpassword')`, userID)
+	assert.NoError(t, err)
+	_, err = conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, revoked) VALUES
+		($1, $3, 'live', $4, false),
+		($2, $3, 'revoked', $4, true)`,
+		live, revoked, userID, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	manager := auth.NewTokenManager("secret", 15*time.Minute)
+	sessions := NewSessionService(conn, manager)
+
+	token, err := manager.GenerateSessionAccessToken(userID, live)
+	assert.NoError(t, err)
+	user, err := sessions.ValidateSession(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+
+	token, err = manager.GenerateSessionAccessToken(userID, revoked)
+	assert.NoError(t, err)
+	_, err = sessions.ValidateSession(context.Background(), token)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+
+	// Logging out deletes the session
+	_, err = conn.Exec(context.Background(), `DELETE FROM refresh_tokens WHERE id = $1`, live)
+	assert.NoError(t, err)
+	token, err = manager.GenerateSessionAccessToken(userID, live)
+	assert.NoError(t, err)
+	_, err = sessions.ValidateSession(context.Background(), token)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+
+	// A session cannot be borrowed by another user
+	_, err = conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at) VALUES ($1, $2, 'other', $3)`,
+		live, uuid.New(), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	_, err = sessions.ValidateSession(context.Background(), token)
+	assert.ErrorIs(t, err, ErrSessionRevoked)
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"todoservice/auth-service/internal/domain"
+	"todoservice/auth-service/internal/repository/postgres"
+	"todoservice/auth-service/internal/repository/redis"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrTokenReuse is returned by Rotate when the presented token was
+	// already rotated, or no longer exists. A rotated token's whole family has
+	// been revoked by then; callers should treat it as theft.
+	ErrTokenReuse = errors.New("refresh token reuse detected")
+	// ErrTokenInvalid is returned by Rotate for a revoked or expired token.
+	ErrTokenInvalid = errors.New("refresh token is revoked or expired")
+)
+
+// TokenService implements refresh token flows on top of RefreshTokenDB,
+// reading through the Redis token cache.
+type TokenService struct {
+	db     postgres.DBTX
+	tokens *postgres.RefreshTokenDB
+	cache  *redis.TokenCache
+	opts   []postgres.Option
+	ttl    time.Duration
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+// NewTokenService returns a service issuing refresh tokens valid for ttl. The
+// options are applied to every RefreshTokenDB it creates. A nil cache is
+// allowed for deployments without Redis; every read then goes to Postgres.
+func NewTokenService(db postgres.DBTX, cache *redis.TokenCache, ttl time.Duration, opts ...postgres.Option) *TokenService {
+	return &TokenService{
+		db:     db,
+		tokens: postgres.NewRefreshTokenDB(db, opts...),
+		cache:  cache,
+		opts:   opts,
+		ttl:    ttl,
+		now:    time.Now,
+		logger: slog.Default(),
+	}
+}
+
+// GetRefreshToken reads the token from the cache and falls back to Postgres
+// on a miss, repopulating the cache for the token's remaining lifetime. Cache
+// failures are logged and served from Postgres.
+func (s *TokenService) GetRefreshToken(ctx context.Context, tokenID uuid.UUID) (*domain.RefreshToken, error) {
+	if s.cache == nil {
+		return s.tokens.Read(ctx, tokenID)
+	}
+
+	token, err := s.cache.Get(ctx, tokenID)
+	if err == nil {
+		return token, nil
+	}
+
+	cacheDown := !errors.Is(err, redis.ErrCacheMiss)
+	if cacheDown {
+		s.logger.WarnContext(ctx, "token cache read failed, falling back to postgres", "error", err)
+	}
+
+	token, err = s.tokens.Read(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cacheDown && token.ExpiresAt.Sub(s.now()) > 0 {
+		if err := s.cache.Set(ctx, token); err != nil {
+			s.logger.WarnContext(ctx, "token cache write failed", "error", err)
+		}
+	}
+
+	return token, nil
+}
+
+// Rotate replaces oldToken with a fresh token of the same family. The old row
+// is locked, marked rotated and the replacement inserted in one transaction,
+// so the user never ends up without a valid token and concurrent rotations of
+// the same token cannot both succeed. Presenting a rotated token again
+// revokes every token of its family.
+func (s *TokenService) Rotate(ctx context.Context, oldToken string) (*domain.RefreshToken, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tokens := postgres.NewRefreshTokenDB(tx, s.opts...)
+
+	old, err := tokens.ReadForRotation(ctx, tx, oldToken)
+	if err != nil {
+		if errors.Is(err, postgres.ErrRefreshTokenNotFound) {
+			return nil, ErrTokenReuse
+		}
+		return nil, err
+	}
+
+	if old.RotatedAt != nil {
+		return nil, s.revokeFamily(ctx, tx, tokens, old)
+	}
+
+	now := s.now()
+	if old.Status(now) != domain.TokenValid {
+		return nil, ErrTokenInvalid
+	}
+
+	if err = tokens.MarkRotated(ctx, old.ID, now); err != nil {
+		return nil, err
+	}
+
+	value, err := newTokenValue()
+	if err != nil {
+		return nil, err
+	}
+
+	token := &domain.RefreshToken{
+		UserID:       old.UserID,
+		RefreshToken: value,
+		ExpiresAt:    now.Add(s.ttl),
+		FamilyID:     old.FamilyID,
+	}
+	if err = tokens.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Delete(ctx, old); err != nil {
+			s.logger.WarnContext(ctx, "token cache delete failed", "error", err)
+		}
+	}
+
+	return token, nil
+}
+
+// revokeFamily handles the reuse of a rotated token: it revokes the token's
+// family, commits and drops the user's cached tokens, which may still show
+// them as valid. It returns ErrTokenReuse on success.
+func (s *TokenService) revokeFamily(ctx context.Context, tx pgx.Tx, tokens *postgres.RefreshTokenDB, reused *domain.RefreshToken) error {
+	revoked, err := tokens.RevokeFamily(ctx, reused.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.WarnContext(ctx, "rotated refresh token reused, token family revoked",
+		"user_id", reused.UserID, "family_id", reused.FamilyID, "revoked", revoked)
+
+	if s.cache != nil {
+		if err := s.cache.DeleteAllForUser(ctx, reused.UserID); err != nil {
+			s.logger.WarnContext(ctx, "token cache delete failed", "error", err)
+		}
+	}
+
+	return ErrTokenReuse
+}
+
+// newTokenValue returns 32 random bytes, base64url encoded.
+func newTokenValue() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
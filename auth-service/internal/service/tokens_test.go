@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"todoservice/auth-service/internal/domain"
+	"todoservice/auth-service/internal/repository/postgres"
+	"todoservice/auth-service/internal/repository/redis"
+)
+
+// testSchema holds the tables the services touch.
+const testSchema = `
+	CREATE TABLE users (
+		id UUID PRIMARY KEY,
+		name VARCHAR(100),
+		email VARCHAR(100) UNIQUE,
+		email_normalized VARCHAR(100) UNIQUE,
+		password_hash VARCHAR(100),
+		phone VARCHAR(16),
+		password_changed_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE refresh_tokens (
+		id UUID PRIMARY KEY,
+		user_id UUID NOT NULL,
+		refresh_token TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		revoked BOOLEAN NOT NULL DEFAULT false,
+		rotated_at TIMESTAMP,
+		family_id UUID,
+		use_count INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+`
+
+// Helper function to setup PostgreSQL container
+func setupPostgres(t *testing.T) (*pgxpool.Pool, func()) {
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:13",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_PASSWORD": "password",
+			"POSTGRES_USER":     "user",
+			"POSTGRES_DB":       "testdb",
+		},
+		WaitingFor: wait.ForListeningPort("5432/tcp"),
+	}
+	postgresContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	assert.NoError(t, err)
+
+	host, err := postgresContainer.Host(ctx)
+	assert.NoError(t, err)
+
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	assert.NoError(t, err)
+
+	dsn := "postgres://user:password@" + host + ":" + port.Port() + "/testdb?sslmode=disable"
+	conn, err := pgxpool.New(context.Background(), dsn)
+	assert.NoError(t, err)
+
+	_, err = conn.Exec(ctx, testSchema)
+	assert.NoError(t, err)
+
+	teardown := func() {
+		conn.Close()
+		postgresContainer.Terminate(ctx)
+	}
+
+	return conn, teardown
+}
+
+func TestTokenService_Rotate(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	userID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New(), userID, "old_refresh_token", time.Now().Add(time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	tokenService := NewTokenService(conn, redis.NewTokenCache(redis.NewMemoryCache()), 24*time.Hour)
+
+	rotated, err := tokenService.Rotate(context.Background(), "old_refresh_token")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, rotated.UserID)
+	assert.NotEqual(t, "old_refresh_token", rotated.RefreshToken)
+
+	tokenDB := postgres.NewRefreshTokenDB(conn)
+
+	previous, err := tokenDB.ReadByRefreshToken(context.Background(), "old_refresh_token")
+	assert.NoError(t, err)
+	assert.True(t, previous.Revoked)
+	assert.NotNil(t, previous.RotatedAt)
+
+	stored, err := tokenDB.ReadByRefreshToken(context.Background(), rotated.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, rotated.ID, stored.ID)
+	assert.Equal(t, previous.FamilyID, stored.FamilyID)
+
+	// Presenting the old token again is reuse
+	_, err = tokenService.Rotate(context.Background(), "old_refresh_token")
+	assert.ErrorIs(t, err, ErrTokenReuse)
+
+	// Unknown tokens are treated as reuse as well
+	_, err = tokenService.Rotate(context.Background(), "unknown_refresh_token")
+	assert.ErrorIs(t, err, ErrTokenReuse)
+}
+
+func TestTokenService_RotateFamily(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	tokenDB := postgres.NewRefreshTokenDB(conn)
+	tokenCache := redis.NewTokenCache(redis.NewMemoryCache())
+	tokenService := NewTokenService(conn, tokenCache, 24*time.Hour)
+
+	// Login starts a family
+	userID := uuid.New()
+	first := &domain.RefreshToken{UserID: userID, RefreshToken: "first_refresh_token", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, tokenDB.Create(context.Background(), first))
+	assert.Equal(t, first.ID, first.FamilyID)
+
+	// An unrelated session of the same user
+	other := &domain.RefreshToken{UserID: userID, RefreshToken: "other_refresh_token", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, tokenDB.Create(context.Background(), other))
+
+	second, err := tokenService.Rotate(context.Background(), "first_refresh_token")
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, second.FamilyID)
+
+	third, err := tokenService.Rotate(context.Background(), second.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, third.FamilyID)
+
+	assert.NoError(t, tokenCache.Set(context.Background(), third))
+
+	// A thief replays the second token: the whole family is revoked
+	_, err = tokenService.Rotate(context.Background(), second.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenReuse)
+
+	for _, id := range []uuid.UUID{first.ID, second.ID, third.ID} {
+		token, err := tokenDB.Read(context.Background(), id)
+		assert.NoError(t, err)
+		assert.True(t, token.Revoked)
+	}
+
+	_, err = tokenCache.Get(context.Background(), third.ID)
+	assert.ErrorIs(t, err, redis.ErrCacheMiss)
+
+	_, err = tokenService.Rotate(context.Background(), third.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+
+	token, err := tokenDB.Read(context.Background(), other.ID)
+	assert.NoError(t, err)
+	assert.False(t, token.Revoked)
+}
+
+func TestTokenService_RotateInvalid(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, revoked, created_at, updated_at) VALUES
+		($1, $3, 'expired', $4, false, $5, $5),
+		($2, $3, 'revoked', $6, true, $5, $5)`,
+		uuid.New(), uuid.New(), uuid.New(), time.Now().Add(-time.Hour), time.Now(), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	tokenService := NewTokenService(conn, redis.NewTokenCache(redis.NewMemoryCache()), 24*time.Hour)
+
+	for _, token := range []string{"expired", "revoked"} {
+		_, err = tokenService.Rotate(context.Background(), token)
+		assert.ErrorIs(t, err, ErrTokenInvalid, token)
+	}
+
+	// Nothing was deleted or created
+	var count int
+	err = conn.QueryRow(context.Background(), `SELECT count(*) FROM refresh_tokens`).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestTokenService_GetRefreshToken(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	cache := redis.NewMemoryCache()
+	tokenCache := redis.NewTokenCache(cache)
+	tokenService := NewTokenService(conn, tokenCache, 24*time.Hour)
+
+	t.Run("cache hit", func(t *testing.T) {
+		// Only cached, so a result proves Postgres was not consulted
+		cached := &domain.RefreshToken{ID: uuid.New(), UserID: uuid.New(), RefreshToken: "cached_refresh_token", ExpiresAt: time.Now().Add(time.Hour)}
+		assert.NoError(t, tokenCache.Set(context.Background(), cached))
+
+		token, err := tokenService.GetRefreshToken(context.Background(), cached.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "cached_refresh_token", token.RefreshToken)
+		assert.Equal(t, cached.UserID, token.UserID)
+	})
+
+	t.Run("cache miss repopulates", func(t *testing.T) {
+		tokenID, userID := uuid.New(), uuid.New()
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			tokenID, userID, "stored_refresh_token", time.Now().Add(time.Hour), time.Now(), time.Now())
+		assert.NoError(t, err)
+
+		token, err := tokenService.GetRefreshToken(context.Background(), tokenID)
+		assert.NoError(t, err)
+		assert.Equal(t, userID, token.UserID)
+
+		cached, err := tokenCache.Get(context.Background(), tokenID)
+		assert.NoError(t, err)
+		assert.Equal(t, userID, cached.UserID)
+	})
+
+	t.Run("expired token is not cached", func(t *testing.T) {
+		tokenID := uuid.New()
+		_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			tokenID, uuid.New(), "expired_refresh_token", time.Now().Add(-time.Hour), time.Now(), time.Now())
+		assert.NoError(t, err)
+
+		_, err = tokenService.GetRefreshToken(context.Background(), tokenID)
+		assert.NoError(t, err)
+
+		_, err = tokenCache.Get(context.Background(), tokenID)
+		assert.ErrorIs(t, err, redis.ErrCacheMiss)
+	})
+}
+
+func TestTokenService_GetRefreshTokenCacheDown(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	// Nothing listens on this port, so every cache call fails
+	client := goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:1"})
+	defer client.Close()
+
+	tokenService := NewTokenService(conn, redis.NewTokenCache(redis.NewRedisCache(client)), 24*time.Hour)
+
+	tokenID := uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		tokenID, uuid.New(), "stored_refresh_token", time.Now().Add(time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	token, err := tokenService.GetRefreshToken(context.Background(), tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, "stored_refresh_token", token.RefreshToken)
+
+	_, err = tokenService.GetRefreshToken(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, postgres.ErrRefreshTokenNotFound)
+}
+
+func TestTokenService_WithoutCache(t *testing.T) {
+	conn, teardown := setupPostgres(t)
+	defer teardown()
+
+	tokenService := NewTokenService(conn, nil, 24*time.Hour)
+
+	tokenID, userID := uuid.New(), uuid.New()
+	_, err := conn.Exec(context.Background(), `INSERT INTO refresh_tokens (id, user_id, refresh_token, expires_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		tokenID, userID, "stored_refresh_token", time.Now().Add(time.Hour), time.Now(), time.Now())
+	assert.NoError(t, err)
+
+	token, err := tokenService.GetRefreshToken(context.Background(), tokenID)
+	assert.NoError(t, err)
+	assert.Equal(t, "stored_refresh_token", token.RefreshToken)
+
+	rotated, err := tokenService.Rotate(context.Background(), "stored_refresh_token")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, rotated.UserID)
+
+	token, err = tokenService.GetRefreshToken(context.Background(), tokenID)
+	assert.NoError(t, err)
+	assert.True(t, token.Revoked)
+
+	token, err = tokenService.GetRefreshToken(context.Background(), rotated.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, rotated.RefreshToken, token.RefreshToken)
+}
@@ -0,0 +1,55 @@
+package testutil
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+
+	"todoservice/auth-service/internal/domain"
+)
+
+// fixtureEpoch anchors fixture timestamps so they do not depend on the wall
+// clock of the machine running the tests.
+var fixtureEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// FakeUser returns a user whose fields are all derived from seed, so that a
+// failing test reproduces exactly on rerun.
+func FakeUser(seed int64) *domain.User {
+	r := rand.New(rand.NewSource(seed))
+
+	createdAt := fixtureEpoch.Add(time.Duration(r.Intn(365*24)) * time.Hour)
+
+	return &domain.User{
+		ID:           fakeUUID(r),
+		Name:         fmt.Sprintf("User %d", seed),
+		Email:        fmt.Sprintf("user%d.%04x@example.com", seed, r.Intn(0x10000)),
+		PasswordHash: fmt.Sprintf("hash-%016x", r.Uint64()),
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}
+}
+
+// FakeToken returns a refresh token for userID whose fields are all derived
+// from seed.
+func FakeToken(seed int64, userID uuid.UUID) *domain.RefreshToken {
+	r := rand.New(rand.NewSource(seed))
+
+	createdAt := fixtureEpoch.Add(time.Duration(r.Intn(365*24)) * time.Hour)
+
+	return &domain.RefreshToken{
+		ID:           fakeUUID(r),
+		UserID:       userID,
+		RefreshToken: fmt.Sprintf("token-%016x%016x", r.Uint64(), r.Uint64()),
+		ExpiresAt:    createdAt.Add(30 * 24 * time.Hour),
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}
+}
+
+func fakeUUID(r *rand.Rand) uuid.UUID {
+	// Reading from a seeded *rand.Rand never fails
+	id, _ := uuid.NewRandomFromReader(r)
+	return id
+}
@@ -0,0 +1,24 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeUser_Deterministic(t *testing.T) {
+	assert.Equal(t, FakeUser(42), FakeUser(42))
+	assert.NotEqual(t, FakeUser(42).ID, FakeUser(43).ID)
+	assert.NotEqual(t, FakeUser(42).Email, FakeUser(43).Email)
+}
+
+func TestFakeToken_Deterministic(t *testing.T) {
+	userID := uuid.New()
+
+	token := FakeToken(7, userID)
+	assert.Equal(t, token, FakeToken(7, userID))
+	assert.Equal(t, userID, token.UserID)
+	assert.True(t, token.ExpiresAt.After(token.CreatedAt))
+	assert.NotEqual(t, token.RefreshToken, FakeToken(8, userID).RefreshToken)
+}